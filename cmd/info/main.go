@@ -0,0 +1,43 @@
+// Command info prints the description and metadata of stored models, e.g.,
+// to tell which model is deployed.
+//
+// Usage:
+//
+//	info <model>...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/ui/repl"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: info <model>...")
+		os.Exit(2)
+	}
+
+	status := 0
+	for i, path := range os.Args[1:] {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		network, err := nn.LoadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "info: %s: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		if len(os.Args) > 2 {
+			fmt.Printf("%s:\n", path)
+		}
+		repl.Info(os.Stdout, network)
+	}
+
+	os.Exit(status)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alan-b-lima/nn-digits/internal/dataset"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// converted is written once a file is converted.
+type converted struct {
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Dest   string `json:"destination"`
+	DType  string `json:"dtype,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+func cmd_convert(w io.Writer, args []string) error {
+	fs := new_flags("convert")
+
+	var (
+		kind  = fs.String("kind", "", "`kind` of file, model or dataset, guessed if unset")
+		dtype = fs.String("dtype", "", "`dtype` of the destination, float64 or float32 for models, uint8 or float32 for datasets, the smallest exact one if unset")
+	)
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usage_error("convert takes a source and a destination")
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	if *kind == "" {
+		*kind = guess_kind(src, dst)
+	}
+
+	result := converted{Kind: *kind, Source: src, Dest: dst}
+
+	switch *kind {
+	case "model":
+		d := nn.DTypeFloat64
+		if *dtype != "" {
+			var err error
+			if d, err = nn.ParseDType(*dtype); err != nil {
+				return usage_error("%v", err)
+			}
+		}
+		if !is_json(dst) {
+			result.DType = d.String()
+		}
+
+		network, err := nn.LoadFile(src)
+		if err != nil {
+			return fmt.Errorf("load model: %w", err)
+		}
+		if err := nn.StoreFile(dst, network, d); err != nil {
+			return fmt.Errorf("store model: %w", err)
+		}
+
+	case "dataset":
+		var d dataset.DType
+		if *dtype != "" {
+			var err error
+			if d, err = dataset.ParseDType(*dtype); err != nil {
+				return usage_error("%v", err)
+			}
+		}
+
+		samples, err := dataset.LoadFile(src, 0)
+		if err != nil {
+			return fmt.Errorf("load dataset: %w", err)
+		}
+
+		// the smallest dtype that keeps the values, as the REPL picks
+		if *dtype == "" {
+			d = dataset.BestDType(samples)
+		}
+		if !is_json(dst) {
+			result.DType = d.String()
+		}
+		if err := dataset.StoreFile(dst, samples, d); err != nil {
+			return fmt.Errorf("store dataset: %w", err)
+		}
+		result.Count = samples.Len()
+
+	default:
+		return usage_error("unknown kind %q, expected model or dataset", *kind)
+	}
+
+	return emit(w, result)
+}
+
+// guess_kind tells models apart from datasets by their extensions or, failing
+// that, by the first bytes of the source.
+func guess_kind(src, dst string) string {
+	for _, path := range []string{src, dst} {
+		switch {
+		case strings.HasSuffix(path, ".nndm"):
+			return "model"
+		case strings.HasSuffix(path, ".nnds"):
+			return "dataset"
+		}
+	}
+
+	if f, err := os.Open(src); err == nil {
+		defer f.Close()
+
+		header := make([]byte, 4)
+		n, _ := io.ReadFull(f, header)
+		if nn.IsBinary(header[:n]) {
+			return "model"
+		}
+	}
+
+	return "dataset"
+}
+
+func is_json(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".json")
+}
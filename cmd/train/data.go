@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alan-b-lima/nn-digits/internal/dataset"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// load_sets loads and concatenates the dataset files of a set, checking they
+// fit the network, whose class names are taken from the datasets if it has
+// none. It also returns the fingerprint of each file.
+func load_sets(network *nn.NeuralNetwork, set string, paths []string) (nn.Dataset, []nn.DatasetInfo, error) {
+	var data nn.Dataset = nn.Samples(nil)
+	var infos []nn.DatasetInfo
+
+	for _, path := range paths {
+		d, err := dataset.OpenFile(path, network.Responses())
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if d.Len() > 0 {
+			first := d.Get(0)
+			if e, i := network.Features(), first.Values.Rows(); e != i {
+				return nil, nil, fmt.Errorf("%s: the network takes %d inputs, but samples have %d", path, e, i)
+			}
+			if e, o := network.Responses(), first.Label.Rows(); e != o {
+				return nil, nil, fmt.Errorf("%s: the network gives %d outputs, but samples have %d", path, e, o)
+			}
+		}
+
+		if names := nn.ClassesOf(d).Names; names != nil {
+			switch current := network.Classes().Names; {
+			case current == nil:
+				network.SetClassNames(names)
+			case !slices.Equal(current, names):
+				return nil, nil, fmt.Errorf("%s: the class names differ from the network's", path)
+			}
+		}
+
+		count, hash := nn.Fingerprint(d)
+		infos = append(infos, nn.DatasetInfo{Set: set, Path: path, Count: count, Hash: hash})
+
+		data = nn.Concat(data, d)
+	}
+
+	return data, infos, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func cmd_evaluate(w io.Writer, args []string) error {
+	fs := new_flags("evaluate")
+
+	var (
+		model = fs.String("model", "", "stored `model` to evaluate")
+		data  list
+		k     = fs.Int("k", 3, "`k` of the top-k accuracy")
+	)
+	fs.Var(&data, "data", "dataset `file` to evaluate against, may be repeated")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *model == "" || len(data) == 0 {
+		return usage_error("both -model and -data are required")
+	}
+	if fs.NArg() > 0 {
+		return usage_error("unexpected argument %q", fs.Arg(0))
+	}
+	if *k < 1 {
+		return usage_error("k must be positive")
+	}
+
+	network, err := nn.LoadFile(*model)
+	if err != nil {
+		return fmt.Errorf("load model: %w", err)
+	}
+
+	samples, _, err := load_sets(network, "tests", data)
+	if err != nil {
+		return fmt.Errorf("load data: %w", err)
+	}
+
+	return emit(w, new_evaluation(network, network.Evaluate(samples, *k)))
+}
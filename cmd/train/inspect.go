@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/alan-b-lima/nn-digits/internal/dataset"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// summary describes a dataset, see [dataset.Summary].
+type summary struct {
+	Path       string   `json:"path"`
+	Count      int      `json:"count"`
+	Features   int      `json:"features"`
+	Outputs    int      `json:"outputs"`
+	Classes    []string `json:"classes,omitempty"`
+	Labels     []int    `json:"labels,omitempty"`
+	Min        float64  `json:"min"`
+	Max        float64  `json:"max"`
+	Mean       float64  `json:"mean"`
+	Std        float64  `json:"std"`
+	Duplicates int      `json:"duplicates"`
+	Hash       string   `json:"hash"`
+}
+
+func cmd_inspect(w io.Writer, args []string) error {
+	fs := new_flags("inspect")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usage_error("inspect takes at least one dataset")
+	}
+
+	for _, path := range fs.Args() {
+		samples, err := dataset.OpenFile(path, 0)
+		if err != nil {
+			return fmt.Errorf("load dataset: %w", err)
+		}
+
+		s := dataset.Summarize(samples)
+		r := summary{
+			Path:       path,
+			Count:      s.Count,
+			Classes:    nn.ClassesOf(samples).Names,
+			Labels:     s.Labels,
+			Min:        s.Min,
+			Max:        s.Max,
+			Duplicates: s.Duplicates,
+		}
+		_, r.Hash = nn.Fingerprint(samples)
+
+		if samples.Len() > 0 {
+			first := samples.Get(0)
+			r.Features, r.Outputs = first.Values.Rows(), first.Label.Rows()
+		}
+
+		// the overall mean and standard deviation, pooled over features
+		var variance float64
+		for j := range s.Mean {
+			r.Mean += s.Mean[j]
+			variance += s.Variance[j] + s.Mean[j]*s.Mean[j]
+		}
+		if n := float64(len(s.Mean)); n > 0 {
+			r.Mean /= n
+			r.Std = math.Sqrt(max(variance/n-r.Mean*r.Mean, 0))
+		}
+
+		if err := emit(w, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,11 +1,132 @@
+// Command train launches the interactive shell when run without arguments, or
+// runs a script of its directives with -f, otherwise it runs one of its
+// non-interactive subcommands, which write their results to the standard
+// output as JSON.
+//
+// Usage:
+//
+//	train [-f <script>]
+//	train train [-config <file>] [flags]
+//	train evaluate -model <model> -data <dataset> [-k <k>]
+//	train predict -model <model> [-deskew] <image>...
+//	train convert [-kind model | dataset] [-dtype <dtype>] <src> <dst>
+//	train inspect <dataset>...
+//
+// The exit status is 0 on success, 1 if the run failed and 2 if it was
+// misused, e.g., with unknown flags or an invalid configuration.
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 
+	"github.com/alan-b-lima/nn-digits/internal/config"
 	"github.com/alan-b-lima/nn-digits/ui/repl"
 )
 
+const (
+	exit_ok      = 0
+	exit_failure = 1
+	exit_usage   = 2
+)
+
+// ErrUsage marks errors in how a subcommand was invoked.
+var ErrUsage = errors.New("usage")
+
+type subcommand func(w io.Writer, args []string) error
+
+var subcommands = map[string]subcommand{
+	"train":    cmd_train,
+	"evaluate": cmd_evaluate,
+	"predict":  cmd_predict,
+	"convert":  cmd_convert,
+	"inspect":  cmd_inspect,
+}
+
+const usage = `usage:
+	train [-f <script>]
+	train train [-config <file>] [flags]
+	train evaluate -model <model> -data <dataset> [-k <k>]
+	train predict -model <model> [-deskew] <image>...
+	train convert [-kind model | dataset] [-dtype <dtype>] <src> <dst>
+	train inspect <dataset>...
+`
+
 func main() {
-	repl.New(os.Stdout, os.Stdin)
+	if len(os.Args) < 2 {
+		if err := repl.New(os.Stdout, os.Stdin); err != nil {
+			os.Exit(exit_failure)
+		}
+		return
+	}
+
+	if os.Args[1] == "-f" {
+		if len(os.Args) != 3 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(exit_usage)
+		}
+
+		if err := repl.Script(os.Stdout, os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "train: %v\n", err)
+			os.Exit(exit_failure)
+		}
+		return
+	}
+
+	cmd, ok := subcommands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exit_usage)
+	}
+
+	err := cmd(os.Stdout, os.Args[2:])
+	switch {
+	case err == nil:
+		os.Exit(exit_ok)
+
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(exit_usage)
+
+	case errors.Is(err, ErrUsage), errors.Is(err, config.ErrInvalid), errors.Is(err, config.ErrUnknownFormat):
+		fmt.Fprintf(os.Stderr, "train %s: %v\n", os.Args[1], err)
+		os.Exit(exit_usage)
+
+	default:
+		fmt.Fprintf(os.Stderr, "train %s: %v\n", os.Args[1], err)
+		os.Exit(exit_failure)
+	}
+}
+
+// new_flags returns a flag set for the named subcommand that reports errors
+// rather than exiting.
+func new_flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("train "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parse parses the arguments of a subcommand, wrapping parse errors in
+// [ErrUsage].
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+
+	return nil
+}
+
+func usage_error(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+// emit writes v as a single line of JSON.
+func emit(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
 }
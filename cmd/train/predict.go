@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alan-b-lima/nn-digits/internal/digits"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// prediction is written for every image, Class is set for classification,
+// Labels, for multi-label tasks, and Output holds the raw output in any case.
+type prediction struct {
+	Path       string    `json:"path"`
+	Class      string    `json:"class,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+	Output     []float64 `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func cmd_predict(w io.Writer, args []string) error {
+	fs := new_flags("predict")
+
+	var (
+		model  = fs.String("model", "", "stored `model` to predict with")
+		deskew = fs.Bool("deskew", false, "deskew the images before predicting")
+	)
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *model == "" || fs.NArg() == 0 {
+		return usage_error("-model and at least one image are required")
+	}
+
+	network, err := nn.LoadFile(*model)
+	if err != nil {
+		return fmt.Errorf("load model: %w", err)
+	}
+	if e, i := network.Features(), len(digits.Request{}); e != i {
+		return fmt.Errorf("the network takes %d inputs, but images have %d", e, i)
+	}
+
+	var opts []digits.Option
+	if *deskew {
+		opts = append(opts, digits.WithDeskew())
+	}
+	classifier := digits.NewClassifier(network, opts...)
+	names := network.Classes()
+
+	var failed int
+	for _, path := range fs.Args() {
+		p := prediction{Path: path}
+
+		output, err := predict(classifier, path)
+		if err != nil {
+			p.Error = err.Error()
+			failed++
+		} else {
+			p.Output = output
+
+			switch network.Task() {
+			case nn.TaskClassification:
+				class := nn.IndexOfMax(output)
+				p.Class, p.Confidence = names.Name(class), output[class]
+
+			case nn.TaskMultiLabel:
+				p.Labels = []string{}
+				for class, v := range output {
+					if v >= nn.Threshold {
+						p.Labels = append(p.Labels, names.Name(class))
+					}
+				}
+			}
+		}
+
+		if err := emit(w, p); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed", failed, fs.NArg())
+	}
+
+	return nil
+}
+
+func predict(classifier digits.Classifier, path string) ([]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	req, err := digits.DecodeImage(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return classifier.Classify(req)
+}
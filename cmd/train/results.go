@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// evaluation is the JSON representation of an [nn.Evaluation], only the
+// metrics meaningful for the task of the network are set.
+type evaluation struct {
+	Task    nn.Task `json:"task"`
+	Total   int     `json:"total"`
+	Correct int     `json:"correct"`
+	Cost    float64 `json:"cost"`
+
+	Accuracy     *float64 `json:"accuracy,omitempty"`
+	K            int      `json:"k,omitempty"`
+	TopKAccuracy *float64 `json:"top_k_accuracy,omitempty"`
+	LogLoss      *float64 `json:"log_loss,omitempty"`
+	HammingLoss  *float64 `json:"hamming_loss,omitempty"`
+
+	Macro   *averages      `json:"macro,omitempty"`
+	Micro   *averages      `json:"micro,omitempty"`
+	Classes []class_result `json:"classes,omitempty"`
+
+	Confusion [][]int `json:"confusion,omitempty"`
+
+	MSE *float64 `json:"mse,omitempty"`
+	MAE *float64 `json:"mae,omitempty"`
+	R2  *float64 `json:"r2,omitempty"`
+}
+
+type averages struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+type class_result struct {
+	Name      string  `json:"name"`
+	Support   int     `json:"support"`
+	Predicted int     `json:"predicted"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+func new_evaluation(network *nn.NeuralNetwork, e *nn.Evaluation) evaluation {
+	r := evaluation{
+		Task:    e.Task,
+		Total:   e.Total,
+		Correct: e.Correct,
+		Cost:    finite(e.Cost),
+	}
+
+	if e.Task == nn.TaskRegression {
+		r.MSE = ptr(finite(e.MSE()))
+		r.MAE = ptr(finite(e.MAE))
+		r.R2 = ptr(finite(e.R2))
+		return r
+	}
+
+	r.Accuracy = ptr(finite(e.Accuracy()))
+	r.LogLoss = ptr(finite(e.LogLoss))
+
+	if e.Task == nn.TaskClassification {
+		r.K = e.K
+		r.TopKAccuracy = ptr(finite(e.TopKAccuracy()))
+		r.Confusion = e.Confusion
+	} else {
+		r.HammingLoss = ptr(finite(e.HammingLoss()))
+	}
+
+	var a averages
+	a.Precision, a.Recall, a.F1 = e.Macro()
+	r.Macro = &a
+
+	var b averages
+	b.Precision, b.Recall, b.F1 = e.Micro()
+	r.Micro = &b
+
+	names := network.Classes()
+	for class := range e.Classes() {
+		r.Classes = append(r.Classes, class_result{
+			Name:      names.Name(class),
+			Support:   e.Support(class),
+			Predicted: e.Predicted(class),
+			Precision: e.Precision(class),
+			Recall:    e.Recall(class),
+			F1:        e.F1(class),
+		})
+	}
+
+	return r
+}
+
+// finite replaces the values JSON cannot represent by zero.
+func finite(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+
+	return v
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
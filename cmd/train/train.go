@@ -0,0 +1,389 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alan-b-lima/nn-digits/internal/config"
+	"github.com/alan-b-lima/nn-digits/internal/metrics"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/internal/tensorboard"
+)
+
+// epoch_result is written after every epoch of training.
+type epoch_result struct {
+	Type       string      `json:"type"`
+	Epoch      int         `json:"epoch"`
+	Rate       float64     `json:"learning_rate"`
+	Cycles     int         `json:"cycles"`
+	Seconds    float64     `json:"seconds"`
+	Validation *evaluation `json:"validation,omitempty"`
+	Checkpoint string      `json:"checkpoint,omitempty"`
+}
+
+// train_result is written once training is done.
+type train_result struct {
+	Type   string      `json:"type"`
+	Epochs int         `json:"epochs"`
+	Cycles int         `json:"cycles"`
+	Output string      `json:"output,omitempty"`
+	Tests  *evaluation `json:"tests,omitempty"`
+}
+
+// list is a flag that may be given several times.
+type list []string
+
+func (l *list) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// dims is a flag of comma-separated dimensions.
+type dims []int
+
+func (d *dims) String() string {
+	return strings.Trim(strings.ReplaceAll(fmt.Sprint([]int(*d)), " ", ","), "[]")
+}
+
+func (d *dims) Set(v string) error {
+	*d = (*d)[:0]
+	for field := range strings.SplitSeq(v, ",") {
+		dim, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return err
+		}
+		*d = append(*d, dim)
+	}
+
+	return nil
+}
+
+func cmd_train(w io.Writer, args []string) error {
+	fs := new_flags("train")
+
+	var (
+		path       = fs.String("config", "", "`file` with the run configuration, in JSON, TOML or YAML")
+		flag_dims  dims
+		model      = fs.String("model", "", "stored `model` to resume training from")
+		task       = fs.String("task", "", "`task` of the network: classification, multilabel or regression")
+		training   list
+		tests      list
+		validation list
+		epochs     = fs.Int("epochs", 0, "number of `epochs`")
+		batch      = fs.Int("batch", 0, "batch `size`")
+		patience   = fs.Int("patience", 0, "`epochs` without improvement to stop after, never if zero")
+		rate       = fs.Float64("rate", 0, "initial learning `rate`")
+		schedule   = fs.String("schedule", "", "learning rate `schedule`: constant, step, exponential or cosine")
+		seed       = fs.Uint64("seed", 0, "`seed` of the weights and the shuffling, random if zero")
+		checkpoint = fs.String("checkpoint", "", "`directory` to store a model onto after every epoch")
+		log        = fs.String("log", "", "`file` to append the metrics of every epoch onto, as JSON lines")
+		board      = fs.String("tensorboard", "", "`directory` to write an event file TensorBoard reads onto")
+		out        = fs.String("out", "", "`path` to store the trained model onto")
+		dtype      = fs.String("dtype", "float64", "`dtype` of the stored models: float64 or float32")
+	)
+	fs.Var(&flag_dims, "dims", "comma-separated `dimensions` of the network")
+	fs.Var(&training, "training", "training dataset `file`, may be repeated")
+	fs.Var(&tests, "tests", "tests dataset `file`, may be repeated")
+	fs.Var(&validation, "validation", "validation dataset `file`, may be repeated")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usage_error("unexpected argument %q", fs.Arg(0))
+	}
+
+	c := config.Default()
+	if *path != "" {
+		loaded, err := config.Load(*path)
+		if err != nil {
+			return err
+		}
+		c = *loaded
+	}
+
+	// flags override the configuration file
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "dims":
+			c.Dims = flag_dims
+		case "model":
+			c.Model = *model
+		case "task":
+			c.Task = *task
+		case "training":
+			c.Datasets.Training = training
+		case "tests":
+			c.Datasets.Tests = tests
+		case "validation":
+			c.Datasets.Validation = validation
+		case "epochs":
+			c.Epochs = *epochs
+		case "batch":
+			c.BatchSize = *batch
+		case "patience":
+			c.Patience = *patience
+		case "rate":
+			c.Optimizer.LearningRate = *rate
+		case "schedule":
+			c.Schedule.Kind = *schedule
+		case "seed":
+			c.Seed = *seed
+		case "checkpoint":
+			c.Checkpoints = *checkpoint
+		case "log":
+			c.Log = *log
+		case "tensorboard":
+			c.TensorBoard = *board
+		case "out":
+			c.Output = *out
+		}
+	})
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	store_dtype, err := nn.ParseDType(*dtype)
+	if err != nil {
+		return usage_error("%v", err)
+	}
+
+	t := nn.TaskClassification
+	if c.Task != "" {
+		t, err = nn.ParseTask(c.Task)
+		if err != nil {
+			return fmt.Errorf("%w: %v", config.ErrInvalid, err)
+		}
+	}
+
+	var rng *rand.Rand
+	if c.Seed != 0 {
+		rng = rand.New(rand.NewPCG(c.Seed, c.Seed))
+	} else {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	network, err := new_network(&c, rng, t)
+	if err != nil {
+		return err
+	}
+
+	meta := network.Metadata()
+
+	training_data, training_infos, err := load_sets(network, "training", c.Datasets.Training)
+	if err != nil {
+		return fmt.Errorf("load training: %w", err)
+	}
+	tests_data, tests_infos, err := load_sets(network, "tests", c.Datasets.Tests)
+	if err != nil {
+		return fmt.Errorf("load tests: %w", err)
+	}
+	validation_data, validation_infos, err := load_sets(network, "validation", c.Datasets.Validation)
+	if err != nil {
+		return fmt.Errorf("load validation: %w", err)
+	}
+	meta.Datasets = slices.Concat(training_infos, tests_infos, validation_infos)
+
+	if training_data.Len() == 0 {
+		return fmt.Errorf("load training: no samples")
+	}
+
+	if c.Checkpoints != "" {
+		if err := os.MkdirAll(c.Checkpoints, 0o755); err != nil {
+			return err
+		}
+	}
+
+	// the network is measured against the validation set along the training,
+	// or the tests set if there is none
+	monitor, monitored := validation_data, "validation"
+	if monitor.Len() == 0 {
+		monitor, monitored = tests_data, "tests"
+	}
+
+	// the evaluation, history, checkpoints and results of every epoch are
+	// handled by the hooks of the trainer, which stop it on failure
+	var failure error
+	fail := func(t *nn.Trainer, err error) {
+		if failure == nil {
+			failure = err
+		}
+		t.Stop()
+	}
+
+	hooks := nn.Hooks{
+		EpochEnd: func(t *nn.Trainer, e nn.EpochEnd) {
+			meta.Cycles = e.Cycle
+			meta.Hyperparameters = nn.Hyperparameters{LearningRate: e.Rate, BatchSize: c.BatchSize}
+
+			result := epoch_result{
+				Type:    "epoch",
+				Epoch:   e.Epoch,
+				Rate:    e.Rate,
+				Cycles:  e.Cycle,
+				Seconds: e.Duration.Seconds(),
+			}
+
+			if monitor.Len() > 0 {
+				eval := t.Evaluate(map[string]nn.Dataset{monitored: monitor}, 1).Evaluations[monitored]
+				v := new_evaluation(network, eval)
+				result.Validation = &v
+
+				meta.History = append(meta.History, nn.Record{
+					Cycle:        e.Cycle,
+					Cost:         eval.Cost,
+					Accuracy:     eval.Accuracy(),
+					LearningRate: e.Rate,
+				})
+			}
+
+			network.SetMetadata(meta)
+
+			if c.Checkpoints != "" {
+				result.Checkpoint = filepath.Join(c.Checkpoints, fmt.Sprintf("epoch-%d.nndm", e.Epoch))
+				if err := nn.StoreFile(result.Checkpoint, network, store_dtype); err != nil {
+					fail(t, fmt.Errorf("checkpoint: %w", err))
+					return
+				}
+			}
+
+			if err := emit(w, result); err != nil {
+				fail(t, err)
+			}
+		},
+		Divergence: func(t *nn.Trainer, d nn.Divergence) {
+			fail(t, fmt.Errorf("the training diverged at cycle %d", d.Cycle))
+		},
+	}
+
+	trainer := nn.Trainer{
+		Network:   network,
+		Cycle:     meta.Cycles,
+		Observers: []nn.Observer{hooks},
+	}
+	if c.Patience > 0 {
+		trainer.Observers = append(trainer.Observers, &nn.EarlyStopping{Set: monitored, Patience: c.Patience})
+	}
+
+	var logger *metrics.Logger
+	if c.Log != "" {
+		// the entries are named after the configuration, if any
+		var name string
+		if *path != "" {
+			name = strings.TrimSuffix(filepath.Base(*path), filepath.Ext(*path))
+		}
+
+		logger, err = metrics.Create(c.Log, name)
+		if err != nil {
+			return fmt.Errorf("log: %w", err)
+		}
+		defer logger.Close()
+
+		trainer.Observers = append(trainer.Observers, logger)
+	}
+
+	var events *tensorboard.Logger
+	if c.TensorBoard != "" {
+		writer, err := tensorboard.Create(c.TensorBoard)
+		if err != nil {
+			return fmt.Errorf("tensorboard: %w", err)
+		}
+
+		events = tensorboard.NewLogger(writer)
+		defer events.Close()
+
+		trainer.Observers = append(trainer.Observers, events)
+	}
+
+	var trained int
+	for epoch := range c.Epochs {
+		if trainer.Stopped() {
+			break
+		}
+
+		trainer.Rate = c.Rate(epoch)
+
+		shuffled := permutation{training_data, rng.Perm(training_data.Len())}
+		trainer.Epoch(epoch+1, nn.Batches(shuffled, c.BatchSize))
+		trained++
+	}
+	if failure != nil {
+		return failure
+	}
+	if logger != nil {
+		if err := logger.Err(); err != nil {
+			return fmt.Errorf("log: %w", err)
+		}
+	}
+	if events != nil {
+		if err := events.Err(); err != nil {
+			return fmt.Errorf("tensorboard: %w", err)
+		}
+	}
+
+	result := train_result{
+		Type:   "done",
+		Epochs: trained,
+		Cycles: meta.Cycles,
+		Output: c.Output,
+	}
+
+	if tests_data.Len() > 0 {
+		v := new_evaluation(network, network.Evaluate(tests_data, 1))
+		result.Tests = &v
+	}
+
+	if c.Output != "" {
+		if err := nn.StoreFile(c.Output, network, store_dtype); err != nil {
+			return fmt.Errorf("store model: %w", err)
+		}
+	}
+
+	return emit(w, result)
+}
+
+// new_network creates the network described by the configuration, or loads
+// it if it resumes from a stored model.
+func new_network(c *config.Config, rng *rand.Rand, task nn.Task) (*nn.NeuralNetwork, error) {
+	if c.Model == "" {
+		network := nn.NewRand(rng, c.Dims...)
+		network.SetTask(task)
+		return network, nil
+	}
+
+	network, err := nn.LoadFile(c.Model)
+	if err != nil {
+		return nil, fmt.Errorf("load model: %w", err)
+	}
+
+	if c.Dims != nil && !slices.Equal(c.Dims, network.Dims()) {
+		return nil, fmt.Errorf("%w: the dimensions differ from the model's, %v", config.ErrInvalid, network.Dims())
+	}
+	if c.Task != "" && task != network.Task() {
+		return nil, fmt.Errorf("%w: the task differs from the model's, %s", config.ErrInvalid, network.Task())
+	}
+
+	return network, nil
+}
+
+// permutation is a view of a dataset in the given order.
+type permutation struct {
+	nn.Dataset
+	order []int
+}
+
+func (p permutation) Get(i int) nn.Sample {
+	return p.Dataset.Get(p.order[i])
+}
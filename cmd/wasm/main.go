@@ -21,7 +21,8 @@ func main() {
 }
 
 var (
-	Array = js.Global().Get("Array")
+	Array      = js.Global().Get("Array")
+	Uint8Array = js.Global().Get("Uint8Array")
 
 	Error     = js.Global().Get("Error")
 	TypeError = js.Global().Get("TypeError")
@@ -35,8 +36,20 @@ func Load(nn *nn.NeuralNetwork) func(js.Value, []js.Value) any {
 
 		var arg js.Value
 
-		if arg = args[0]; arg.Type() != js.TypeString {
-			return TypeError.New("data is not a string")
+		// binary models come as bytes, JSON exports as strings
+		if arg = args[0]; arg.InstanceOf(Uint8Array) {
+			buf := make([]byte, arg.Get("length").Int())
+			js.CopyBytesToGo(buf, arg)
+
+			if err := nn.UnmarshalBinary(buf); err != nil {
+				return Error.New(err.Error())
+			}
+
+			return nil
+		}
+
+		if arg.Type() != js.TypeString {
+			return TypeError.New("data is not a string or an Uint8Array")
 		}
 		j := arg.String()
 
@@ -0,0 +1,107 @@
+// Package augment implements random transformations of square grayscale
+// images, such as the 28x28 digits of MNIST, used to augment datasets during
+// training.
+package augment
+
+import (
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// Transform is a random transformation of a square image.
+type Transform interface {
+	// Apply transforms the image in place. The image is laid out row by row
+	// and has the given side, its values are expected to be in [0, 1].
+	Apply(image []float64, side int, rng *rand.Rand)
+
+	// String describes the transformation and its parameters.
+	String() string
+}
+
+// Pipeline is a sequence of transformations, applied in order.
+type Pipeline []Transform
+
+func (p Pipeline) Apply(image []float64, side int, rng *rand.Rand) {
+	for _, t := range p {
+		t.Apply(image, side, rng)
+	}
+}
+
+func (p Pipeline) String() string {
+	s := make([]string, len(p))
+	for i, t := range p {
+		s[i] = t.String()
+	}
+
+	return strings.Join(s, " | ")
+}
+
+// Dataset returns a view of the dataset in which every sample returned by Get
+// is a copy of the original, transformed by the pipeline. The images are
+// assumed to be square. Each call of Get yields a different transformation.
+func Dataset(d nn.Dataset, p Pipeline) nn.Dataset {
+	return augmented{d, p}
+}
+
+type augmented struct {
+	nn.Dataset
+	pipeline Pipeline
+}
+
+func (a augmented) Get(i int) nn.Sample {
+	sample := a.Dataset.Get(i)
+
+	values := nnmath.MakeVec(sample.Values.Size())
+	nnmath.Assign(values, sample.Values)
+
+	data := values.Data()
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	a.pipeline.Apply(data, Side(len(data)), rng)
+
+	return nn.Sample{
+		Label:  sample.Label,
+		Values: values,
+	}
+}
+
+// Side returns the side of a square image with the given number of pixels,
+// rounded down.
+func Side(pixels int) int {
+	side := 0
+	for (side+1)*(side+1) <= pixels {
+		side++
+	}
+
+	return side
+}
+
+// sample returns the value of the image at the real coordinates (x, y) with
+// bilinear interpolation, pixels outside of the image are 0.
+func sample(image []float64, side int, x, y float64) float64 {
+	at := func(x, y int) float64 {
+		if x < 0 || side <= x || y < 0 || side <= y {
+			return 0
+		}
+		return image[y*side+x]
+	}
+
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+
+	return top*(1-fy) + bottom*fy
+}
+
+func uniform(rng *rand.Rand, r float64) float64 {
+	return (2*rng.Float64() - 1) * r
+}
+
+func clamp(v float64) float64 {
+	return min(max(v, 0), 1)
+}
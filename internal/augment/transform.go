@@ -0,0 +1,251 @@
+package augment
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Affine rotates, scales, shears and translates the image about its center,
+// each parameter is drawn uniformly from the given range.
+type Affine struct {
+	// Rotate is the maximum rotation in degrees, either way.
+	Rotate float64
+
+	// Scale is the maximum relative change in size, the image is scaled
+	// by a factor in [1 - Scale, 1 + Scale].
+	Scale float64
+
+	// Shear is the maximum horizontal shear factor, either way.
+	Shear float64
+
+	// Translate is the maximum translation in pixels, in each axis.
+	Translate float64
+}
+
+func (a Affine) Apply(image []float64, side int, rng *rand.Rand) {
+	theta := uniform(rng, a.Rotate) * math.Pi / 180
+	scale := 1 + uniform(rng, a.Scale)
+	shear := uniform(rng, a.Shear)
+	tx, ty := uniform(rng, a.Translate), uniform(rng, a.Translate)
+
+	// forward transform M = R * Sh * S, mapped from the output back onto
+	// the input by its inverse
+	sin, cos := math.Sincos(theta)
+	m00, m01 := scale*cos, scale*(cos*shear-sin)
+	m10, m11 := scale*sin, scale*(sin*shear+cos)
+
+	det := m00*m11 - m01*m10
+	if det == 0 {
+		return
+	}
+	i00, i01 := m11/det, -m01/det
+	i10, i11 := -m10/det, m00/det
+
+	src := slices.Clone(image)
+	center := float64(side-1) / 2
+
+	for y := range side {
+		for x := range side {
+			dx := float64(x) - center - tx
+			dy := float64(y) - center - ty
+
+			sx := i00*dx + i01*dy + center
+			sy := i10*dx + i11*dy + center
+
+			image[y*side+x] = sample(src, side, sx, sy)
+		}
+	}
+}
+
+func (a Affine) String() string {
+	return fmt.Sprintf("affine rotate=%g scale=%g shear=%g translate=%g", a.Rotate, a.Scale, a.Shear, a.Translate)
+}
+
+// Elastic displaces every pixel by a random field smoothed with a Gaussian
+// kernel, as described by Simard, Steinkraus and Platt (2003).
+type Elastic struct {
+	// Alpha is the intensity of the displacement, in pixels.
+	Alpha float64
+
+	// Sigma is the standard deviation of the smoothing kernel, in pixels.
+	Sigma float64
+}
+
+func (e Elastic) Apply(image []float64, side int, rng *rand.Rand) {
+	dx := make([]float64, len(image))
+	dy := make([]float64, len(image))
+
+	for i := range image {
+		dx[i] = 2*rng.Float64() - 1
+		dy[i] = 2*rng.Float64() - 1
+	}
+
+	kernel := gaussian(e.Sigma)
+	blur(dx, side, kernel)
+	blur(dy, side, kernel)
+
+	src := slices.Clone(image)
+	for y := range side {
+		for x := range side {
+			i := y*side + x
+			image[i] = sample(src, side, float64(x)+e.Alpha*dx[i], float64(y)+e.Alpha*dy[i])
+		}
+	}
+}
+
+func (e Elastic) String() string {
+	return fmt.Sprintf("elastic alpha=%g sigma=%g", e.Alpha, e.Sigma)
+}
+
+// gaussian returns a normalized one-dimensional Gaussian kernel.
+func gaussian(sigma float64) []float64 {
+	if sigma <= 0 {
+		return []float64{1}
+	}
+
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+
+	var sum float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	return kernel
+}
+
+// blur convolves the image with the kernel horizontally and then vertically.
+func blur(image []float64, side int, kernel []float64) {
+	radius := len(kernel) / 2
+	tmp := make([]float64, len(image))
+
+	for y := range side {
+		for x := range side {
+			var sum float64
+			for k, w := range kernel {
+				if sx := x + k - radius; 0 <= sx && sx < side {
+					sum += w * image[y*side+sx]
+				}
+			}
+			tmp[y*side+x] = sum
+		}
+	}
+
+	for y := range side {
+		for x := range side {
+			var sum float64
+			for k, w := range kernel {
+				if sy := y + k - radius; 0 <= sy && sy < side {
+					sum += w * tmp[sy*side+x]
+				}
+			}
+			image[y*side+x] = sum
+		}
+	}
+}
+
+// Noise adds Gaussian noise to every pixel.
+type Noise struct {
+	// Sigma is the standard deviation of the noise.
+	Sigma float64
+}
+
+func (n Noise) Apply(image []float64, side int, rng *rand.Rand) {
+	for i := range image {
+		image[i] = clamp(image[i] + n.Sigma*rng.NormFloat64())
+	}
+}
+
+func (n Noise) String() string {
+	return fmt.Sprintf("noise sigma=%g", n.Sigma)
+}
+
+// Morphology thickens or thins the strokes by a random number of grayscale
+// dilations or erosions with a 3x3 cross.
+type Morphology struct {
+	// Max is the maximum number of iterations, the image is dilated k
+	// times for k > 0 and eroded -k times for k < 0, k in [-Max, Max].
+	Max int
+}
+
+func (m Morphology) Apply(image []float64, side int, rng *rand.Rand) {
+	if m.Max <= 0 {
+		return
+	}
+
+	k := rng.IntN(2*m.Max+1) - m.Max
+
+	op := func(a, b float64) float64 { return max(a, b) }
+	if k < 0 {
+		op, k = func(a, b float64) float64 { return min(a, b) }, -k
+	}
+
+	src := make([]float64, len(image))
+	for range k {
+		copy(src, image)
+
+		for y := range side {
+			for x := range side {
+				v := src[y*side+x]
+				if x > 0 {
+					v = op(v, src[y*side+x-1])
+				}
+				if x < side-1 {
+					v = op(v, src[y*side+x+1])
+				}
+				if y > 0 {
+					v = op(v, src[(y-1)*side+x])
+				}
+				if y < side-1 {
+					v = op(v, src[(y+1)*side+x])
+				}
+				image[y*side+x] = v
+			}
+		}
+	}
+}
+
+func (m Morphology) String() string {
+	return fmt.Sprintf("morphology max=%d", m.Max)
+}
+
+// Erase zeros out a random rectangle of the image, as described by Zhong et
+// al. (2017).
+type Erase struct {
+	// Probability is the chance of erasing anything at all.
+	Probability float64
+
+	// Area is the maximum area of the rectangle, relative to the image.
+	Area float64
+}
+
+func (e Erase) Apply(image []float64, side int, rng *rand.Rand) {
+	if side == 0 || rng.Float64() >= e.Probability {
+		return
+	}
+
+	area := rng.Float64() * e.Area * float64(side*side)
+	aspect := math.Exp(uniform(rng, math.Log(3)))
+
+	w := min(max(int(math.Sqrt(area*aspect)), 1), side)
+	h := min(max(int(math.Sqrt(area/aspect)), 1), side)
+
+	x0, y0 := rng.IntN(side-w+1), rng.IntN(side-h+1)
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			image[y*side+x] = 0
+		}
+	}
+}
+
+func (e Erase) String() string {
+	return fmt.Sprintf("erase probability=%g area=%g", e.Probability, e.Area)
+}
@@ -0,0 +1,239 @@
+// Package config reads the run configurations of the non-interactive trainer,
+// from JSON, TOML or YAML files.
+//
+// Only the subsets of TOML and YAML needed for configurations are supported:
+// tables or nested mappings, strings, numbers, booleans and arrays of those.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config is the configuration of a training run.
+type Config struct {
+	// Dims are the dimensions of the network, counting the input layer.
+	Dims []int `json:"dims"`
+
+	// Model, if set, is the path of a stored model to resume training from,
+	// in which case Dims and Task must be either unset or match it.
+	Model string `json:"model,omitempty"`
+
+	// Task is the task of the network, see [nn.ParseTask], classification
+	// if unset, or that of Model.
+	Task string `json:"task,omitempty"`
+
+	// Activation is the activation of the hidden layers, only "relu" is
+	// supported.
+	Activation string `json:"activation,omitempty"`
+
+	Optimizer Optimizer `json:"optimizer"`
+	Schedule  Schedule  `json:"schedule"`
+	Datasets  Datasets  `json:"datasets"`
+
+	Epochs    int `json:"epochs"`
+	BatchSize int `json:"batch_size"`
+
+	// Patience, if set, stops the training once the cost against the
+	// validation data, or the tests data, has not decreased for that many
+	// epochs in a row.
+	Patience int `json:"patience,omitempty"`
+
+	// Seed seeds the initial weights and the shuffling of the batches, the
+	// run is not reproducible if it is zero.
+	Seed uint64 `json:"seed,omitempty"`
+
+	// Checkpoints, if set, is the directory the model is stored onto at the
+	// end of every epoch.
+	Checkpoints string `json:"checkpoint_dir,omitempty"`
+
+	// Log, if set, is the file the metrics of every epoch are appended onto,
+	// as JSON lines, see [metrics.Logger].
+	Log string `json:"log,omitempty"`
+
+	// TensorBoard, if set, is the directory an event file TensorBoard reads
+	// is written onto, see [tensorboard.Logger].
+	TensorBoard string `json:"tensorboard_dir,omitempty"`
+
+	// Output, if set, is the path the trained model is stored onto.
+	Output string `json:"output,omitempty"`
+}
+
+// Optimizer configures how the gradient is applied, only plain stochastic
+// gradient descent, "sgd", is supported.
+type Optimizer struct {
+	Name         string  `json:"name,omitempty"`
+	LearningRate float64 `json:"learning_rate"`
+}
+
+// Schedule configures how the learning rate changes from epoch to epoch.
+type Schedule struct {
+	// Kind is one of:
+	//   - "constant", the default;
+	//   - "step", multiplied by Factor every Every epochs;
+	//   - "exponential", multiplied by Factor every epoch;
+	//   - "cosine", annealed from the initial rate down to Min.
+	Kind   string  `json:"kind,omitempty"`
+	Every  int     `json:"every,omitempty"`
+	Factor float64 `json:"factor,omitempty"`
+	Min    float64 `json:"min,omitempty"`
+}
+
+// Datasets are the paths of the dataset files of each set, in any format
+// understood by [dataset.OpenFile]. IDX images take their labels from the
+// file named after them.
+type Datasets struct {
+	Training   []string `json:"training"`
+	Tests      []string `json:"tests,omitempty"`
+	Validation []string `json:"validation,omitempty"`
+}
+
+var (
+	ErrUnknownFormat = errors.New("config: unknown format, expected .json, .toml, .yaml or .yml")
+	ErrInvalid       = errors.New("config: invalid configuration")
+)
+
+// Default returns the configuration every other is applied over.
+func Default() Config {
+	return Config{
+		Activation: "relu",
+		Optimizer:  Optimizer{Name: "sgd", LearningRate: .1},
+		Schedule:   Schedule{Kind: "constant"},
+		Epochs:     1,
+		BatchSize:  32,
+	}
+}
+
+// Load reads the configuration in the file at path, the format is given by its
+// extension. Fields missing from the file are left as in [Default]. Malformed
+// files are reported as [ErrInvalid].
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var j []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		j = data
+
+	case ".toml":
+		m, err := parse_toml(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, path, err)
+		}
+		j, err = json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+	case ".yaml", ".yml":
+		m, err := parse_yaml(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, path, err)
+		}
+		j, err = json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	c := Default()
+
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, path, err)
+	}
+
+	return &c, nil
+}
+
+// Validate reports the first problem with the configuration, if any.
+func (c *Config) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+	}
+
+	if c.Model == "" && len(c.Dims) < 2 {
+		return invalid("there must be at least two dimensions")
+	}
+	for _, dim := range c.Dims {
+		if dim < 1 {
+			return invalid("dimensions must be positive")
+		}
+	}
+
+	if c.Activation != "relu" {
+		return invalid("unsupported activation %q", c.Activation)
+	}
+	if c.Optimizer.Name != "sgd" {
+		return invalid("unsupported optimizer %q", c.Optimizer.Name)
+	}
+	if c.Optimizer.LearningRate <= 0 {
+		return invalid("the learning rate must be positive")
+	}
+
+	switch c.Schedule.Kind {
+	case "constant", "cosine":
+	case "step":
+		if c.Schedule.Every < 1 {
+			return invalid("step schedules must set every to a positive number of epochs")
+		}
+		fallthrough
+	case "exponential":
+		if c.Schedule.Factor <= 0 {
+			return invalid("%s schedules must set a positive factor", c.Schedule.Kind)
+		}
+	default:
+		return invalid("unknown schedule %q", c.Schedule.Kind)
+	}
+
+	if len(c.Datasets.Training) == 0 {
+		return invalid("there must be training data")
+	}
+	if c.Epochs < 1 {
+		return invalid("epochs must be positive")
+	}
+	if c.BatchSize < 1 {
+		return invalid("batch size must be positive")
+	}
+	if c.Patience < 0 {
+		return invalid("patience must not be negative")
+	}
+
+	return nil
+}
+
+// Rate returns the learning rate for the given epoch, counting from zero, as
+// given by the schedule.
+func (c *Config) Rate(epoch int) float64 {
+	rate := c.Optimizer.LearningRate
+	s := c.Schedule
+
+	switch s.Kind {
+	case "step":
+		rate *= math.Pow(s.Factor, float64(epoch/s.Every))
+
+	case "exponential":
+		rate *= math.Pow(s.Factor, float64(epoch))
+
+	case "cosine":
+		if c.Epochs > 1 {
+			progress := float64(epoch) / float64(c.Epochs-1)
+			rate = s.Min + (rate-s.Min)*(1+math.Cos(math.Pi*progress))/2
+		}
+	}
+
+	return rate
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// formats are the same configuration in every supported format.
+var formats = map[string]string{
+	"run.json": `{
+	"dims": [784, 16, 10],
+	"optimizer": {"name": "sgd", "learning_rate": 0.05},
+	"schedule": {"kind": "step", "every": 2, "factor": 0.5},
+	"datasets": {
+		"training": ["runs/a#1.csv", "b.nnds"],
+		"validation": ["c.nnds"]
+	},
+	"epochs": 4,
+	"batch_size": 64,
+	"patience": 2,
+	"seed": 42,
+	"log": "metrics.jsonl"
+}
+`,
+	"run.yaml": `# a run
+dims: [784, 16, 10]
+optimizer:
+  name: sgd
+  learning_rate: 0.05
+schedule: {kind: step, every: 2, factor: 0.5}
+datasets:
+  training:
+    - runs/a#1.csv # comments need whitespace before them
+    - b.nnds
+  validation: [c.nnds]
+epochs: 4
+batch_size: 64
+patience: 2
+seed: 42
+log: 'metrics.jsonl'
+`,
+	"run.toml": `# a run
+dims = [784, 16, 10]
+epochs = 4
+batch_size = 64
+patience = 2
+seed = 42
+log = "metrics.jsonl"
+
+[optimizer]
+name = "sgd"
+learning_rate = 0.05
+
+[schedule]
+kind = "step"
+every = 2
+factor = 0.5
+
+[datasets]
+training = [
+	"runs/a#1.csv",
+	"b.nnds",
+]
+validation = ["c.nnds"]
+`,
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	want := Default()
+	want.Dims = []int{784, 16, 10}
+	want.Optimizer = Optimizer{Name: "sgd", LearningRate: 0.05}
+	want.Schedule = Schedule{Kind: "step", Every: 2, Factor: 0.5}
+	want.Datasets = Datasets{Training: []string{"runs/a#1.csv", "b.nnds"}, Validation: []string{"c.nnds"}}
+	want.Epochs = 4
+	want.BatchSize = 64
+	want.Patience = 2
+	want.Seed = 42
+	want.Log = "metrics.jsonl"
+
+	for name, doc := range formats {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("Load:\ngot  %+v\nwant %+v", *got, want)
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		doc  string
+		want error
+	}{
+		{"run.ini", "epochs = 1\n", ErrUnknownFormat},
+		{"unknown.json", `{"epoch": 1}`, ErrInvalid},
+		{"unknown.yaml", "epoch: 1\n", ErrInvalid},
+		{"type.toml", "epochs = \"one\"\n", ErrInvalid},
+		{"syntax.yml", "epochs: [1\n", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.doc), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Load(path); !errors.Is(err, tt.want) {
+				t.Errorf("Load: got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parse_toml parses the subset of TOML used by configurations: tables, dotted
+// keys, basic and literal strings, integers, floats, booleans, arrays, which
+// may span several lines, and inline tables. Arrays of tables, multi-line
+// strings and dates are not supported.
+func parse_toml(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+
+	lines := strings.Split(string(data), "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(strip_comment(lines[n]))
+		number := n + 1
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", number)
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", number)
+			}
+
+			keys, err := toml_keys(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+
+			table, err = toml_table(root, keys)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", number)
+		}
+		value = strings.TrimSpace(value)
+
+		// arrays may span several lines, until their brackets balance
+		for open_brackets(value) > 0 && n+1 < len(lines) {
+			n++
+			value += " " + strings.TrimSpace(strip_comment(lines[n]))
+		}
+
+		keys, err := toml_keys(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		v, rest, err := toml_value(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", number, rest)
+		}
+
+		if err := toml_set(table, keys, v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+	}
+
+	return root, nil
+}
+
+// toml_keys splits a dotted key into its parts, which may be quoted, dots
+// within quotes being part of the key.
+func toml_keys(key string) ([]string, error) {
+	var keys []string
+	for key != "" {
+		var part string
+
+		key = strings.TrimSpace(key)
+		if len(key) > 0 && (key[0] == '"' || key[0] == '\'') {
+			end := strings.IndexByte(key[1:], key[0])
+			if end < 0 {
+				return nil, errors.New("unterminated key")
+			}
+
+			part, key = key[1:end+1], strings.TrimSpace(key[end+2:])
+			if key != "" && key[0] != '.' {
+				return nil, fmt.Errorf("unexpected %q after key", key)
+			}
+		} else {
+			end := strings.IndexByte(key, '.')
+			if end < 0 {
+				end = len(key)
+			}
+
+			part, key = strings.TrimSpace(key[:end]), key[end:]
+			if part == "" {
+				return nil, errors.New("empty key")
+			}
+		}
+
+		keys = append(keys, part)
+
+		if key != "" {
+			if key = key[1:]; strings.TrimSpace(key) == "" {
+				return nil, errors.New("empty key")
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("empty key")
+	}
+
+	return keys, nil
+}
+
+// toml_table returns the table at the given path, creating the missing ones.
+func toml_table(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			t := map[string]any{}
+			table[key] = t
+			table = t
+		case map[string]any:
+			table = next
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+
+	return table, nil
+}
+
+func toml_set(table map[string]any, keys []string, v any) error {
+	table, err := toml_table(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	if _, in := table[key]; in {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+
+	table[key] = v
+	return nil
+}
+
+// toml_value parses the value at the start of s and returns the rest.
+func toml_value(s string) (any, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if s == "" {
+		return nil, "", errors.New("missing value")
+	}
+
+	switch s[0] {
+	case '"':
+		end := closing_quote(s)
+		if end < 0 {
+			return nil, "", errors.New("unterminated string")
+		}
+
+		str, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, "", fmt.Errorf("bad string %s", s[:end+1])
+		}
+		return str, s[end+1:], nil
+
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+
+	case '[':
+		array := []any{}
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " \t,")
+			if strings.HasPrefix(s, "]") {
+				return array, s[1:], nil
+			}
+
+			v, rest, err := toml_value(s)
+			if err != nil {
+				return nil, "", err
+			}
+			array = append(array, v)
+
+			s = strings.TrimLeft(rest, " \t")
+			if !strings.HasPrefix(s, ",") && !strings.HasPrefix(s, "]") {
+				return nil, "", errors.New("expected , or ] in array")
+			}
+		}
+
+	case '{':
+		table := map[string]any{}
+		s = strings.TrimLeft(s[1:], " \t")
+		if strings.HasPrefix(s, "}") {
+			return table, s[1:], nil
+		}
+
+		for {
+			key, rest, ok := strings.Cut(s, "=")
+			if !ok {
+				return nil, "", errors.New("expected key = value in inline table")
+			}
+
+			keys, err := toml_keys(key)
+			if err != nil {
+				return nil, "", err
+			}
+
+			v, rest, err := toml_value(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			if err := toml_set(table, keys, v); err != nil {
+				return nil, "", err
+			}
+
+			s = strings.TrimLeft(rest, " \t")
+			switch {
+			case strings.HasPrefix(s, "}"):
+				return table, s[1:], nil
+			case strings.HasPrefix(s, ","):
+				s = s[1:]
+			default:
+				return nil, "", errors.New("expected , or } in inline table")
+			}
+		}
+	}
+
+	end := strings.IndexAny(s, ",]} \t")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, "", fmt.Errorf("%s is not supported", word)
+	}
+
+	number := strings.ReplaceAll(word, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return i, rest, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, rest, nil
+	}
+
+	return nil, "", fmt.Errorf("bad value %q", word)
+}
+
+// closing_quote returns the index of the quote closing the basic string at
+// the start of s, or -1 if there is none.
+func closing_quote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// strip_comment removes a # comment from the line, unless within quotes.
+func strip_comment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == 0 && c == '#':
+			return line[:i]
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+
+	return line
+}
+
+// open_brackets returns how many brackets are left open in s, outside quotes.
+func open_brackets(s string) int {
+	var depth int
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == 0 && (c == '[' || c == '{'):
+			depth++
+		case quote == 0 && (c == ']' || c == '}'):
+			depth--
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+
+	return depth
+}
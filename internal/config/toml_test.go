@@ -0,0 +1,129 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want map[string]any
+	}{
+		{
+			name: "empty",
+			doc:  "# nothing\n\n",
+			want: map[string]any{},
+		},
+		{
+			name: "scalars",
+			doc: "a = 1\n" +
+				"b = 0.5\n" +
+				"c = true\n" +
+				"d = \"quoted\\n\"\n" +
+				"e = 'literal\\n'\n" +
+				"f = 1_000\n" +
+				"g = 0x10\n",
+			want: map[string]any{
+				"a": int64(1), "b": 0.5, "c": true, "d": "quoted\n",
+				"e": `literal\n`, "f": int64(1000), "g": int64(16),
+			},
+		},
+		{
+			name: "tables",
+			doc: "epochs = 3\n" +
+				"[optimizer]\n" +
+				"name = \"sgd\"\n" +
+				"[optimizer.schedule]\n" +
+				"kind = \"step\"\n",
+			want: map[string]any{
+				"epochs": int64(3),
+				"optimizer": map[string]any{
+					"name":     "sgd",
+					"schedule": map[string]any{"kind": "step"},
+				},
+			},
+		},
+		{
+			name: "dotted keys",
+			doc: "optimizer.name = \"sgd\"\n" +
+				"optimizer.learning_rate = 0.1\n" +
+				"\"a.b\".c = 1\n",
+			want: map[string]any{
+				"optimizer": map[string]any{"name": "sgd", "learning_rate": 0.1},
+				"a.b":       map[string]any{"c": int64(1)},
+			},
+		},
+		{
+			name: "arrays and inline tables",
+			doc: "dims = [784, 16, 10]\n" +
+				"nested = [[1, 2], [\"a, b\"]]\n" +
+				"optimizer = { name = \"sgd\", learning_rate = 0.1 }\n" +
+				"empty = []\n",
+			want: map[string]any{
+				"dims":      []any{int64(784), int64(16), int64(10)},
+				"nested":    []any{[]any{int64(1), int64(2)}, []any{"a, b"}},
+				"optimizer": map[string]any{"name": "sgd", "learning_rate": 0.1},
+				"empty":     []any{},
+			},
+		},
+		{
+			name: "multi-line arrays",
+			doc: "training = [\n" +
+				"  \"a.csv\", # first\n" +
+				"  \"b#2.csv\",\n" +
+				"]\n" +
+				"epochs = 2\n",
+			want: map[string]any{
+				"training": []any{"a.csv", "b#2.csv"},
+				"epochs":   int64(2),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse_toml([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("parse_toml: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse_toml:\ngot  %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"duplicate key", "a = 1\nb = 2\na = 3\n", "line 3: duplicate key \"a\""},
+		{"duplicate dotted key", "[t]\na.b = 1\n[t.a]\nb = 2\n", "line 4: duplicate key \"b\""},
+		{"not a table", "a = 1\n[a]\n", "line 2: key \"a\" is not a table"},
+		{"empty key", "a..b = 1\n", "line 1: empty key"},
+		{"trailing dot", "a. = 1\n", "line 1: empty key"},
+		{"unterminated key", "\"a = 1\n", "line 1: unterminated key"},
+		{"array of tables", "[[runs]]\n", "line 1: arrays of tables"},
+		{"unterminated header", "[a\n", "line 1: unterminated table header"},
+		{"missing equals", "a = 1\nb\n", "line 2: expected key = value"},
+		{"missing value", "a =\n", "line 1: missing value"},
+		{"bad value", "a = yes\n", "line 1: bad value \"yes\""},
+		{"unterminated string", "a = \"x\n", "line 1: unterminated string"},
+		{"trailing", "a = 1 2\n", "line 1: unexpected"},
+		{"infinity", "a = inf\n", "line 1: inf is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse_toml([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parse_toml: got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
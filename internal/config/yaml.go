@@ -0,0 +1,360 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yaml_line is a non-empty line of a YAML document, without its comment.
+type yaml_line struct {
+	number  int
+	indent  int
+	content string
+}
+
+// parse_yaml parses the subset of YAML used by configurations: block mappings
+// and sequences nested by indentation, flow sequences and mappings, quoted and
+// plain scalars. Anchors, tags, multi-line scalars and multiple documents are
+// not supported.
+func parse_yaml(data []byte) (map[string]any, error) {
+	var lines []yaml_line
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(yaml_comment(line), " \t\r")
+
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content == "---" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+
+		lines = append(lines, yaml_line{i + 1, len(line) - len(content), content})
+	}
+
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	v, n, err := yaml_block(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if n < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[n].number)
+	}
+
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, errors.New("the document must be a mapping")
+	}
+
+	return m, nil
+}
+
+// yaml_block parses the block starting at lines[i], whose lines are indented
+// by indent, and returns the index of the first line after it.
+func yaml_block(lines []yaml_line, i, indent int) (any, int, error) {
+	if is_item(lines[i].content) {
+		return yaml_sequence(lines, i, indent)
+	}
+
+	return yaml_mapping(lines, i, indent)
+}
+
+func yaml_sequence(lines []yaml_line, i, indent int) (any, int, error) {
+	seq := []any{}
+	for i < len(lines) && lines[i].indent == indent && is_item(lines[i].content) {
+		line := lines[i]
+		content := strings.TrimLeft(strings.TrimPrefix(line.content, "-"), " ")
+
+		switch {
+		case content == "":
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				seq = append(seq, nil)
+				i++
+				continue
+			}
+
+			v, n, err := yaml_block(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			seq, i = append(seq, v), n
+
+		case is_key(content):
+			// "- key: value" starts a mapping indented past the dash
+			inner := indent + len(line.content) - len(content)
+			lines[i] = yaml_line{line.number, inner, content}
+
+			v, n, err := yaml_mapping(lines, i, inner)
+			if err != nil {
+				return nil, 0, err
+			}
+			seq, i = append(seq, v), n
+
+		default:
+			v, err := yaml_scalar(content)
+			if err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", line.number, err)
+			}
+			seq, i = append(seq, v), i+1
+		}
+	}
+
+	return seq, i, nil
+}
+
+func yaml_mapping(lines []yaml_line, i, indent int) (any, int, error) {
+	m := map[string]any{}
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if !is_key(line.content) {
+			return nil, 0, fmt.Errorf("line %d: expected key: value", line.number)
+		}
+
+		key, value := split_key(line.content)
+		if _, in := m[key]; in {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+
+		if value != "" {
+			v, err := yaml_scalar(value)
+			if err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", line.number, err)
+			}
+			m[key], i = v, i+1
+			continue
+		}
+
+		i++
+		switch {
+		// sequences may be indented as much as their key
+		case i < len(lines) && lines[i].indent == indent && is_item(lines[i].content):
+			v, n, err := yaml_sequence(lines, i, indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = v, n
+
+		case i < len(lines) && lines[i].indent > indent:
+			v, n, err := yaml_block(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = v, n
+
+		default:
+			m[key] = nil
+		}
+	}
+
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+
+	return m, i, nil
+}
+
+// yaml_comment strips the comment off a line. Unlike in TOML, a comment
+// starts at a # at the start of the line or after whitespace, so "a#1" is a
+// plain scalar, and quotes only open scalars where they may start, after
+// indentation, a dash, a colon or flow punctuation, so apostrophes within
+// plain scalars, as in "don't", do not.
+func yaml_comment(line string) string {
+	var quote, prev byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case quote == 0 && c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		case quote == 0 && (c == '"' || c == '\'') && (prev == 0 || strings.IndexByte("-:[{,?", prev) >= 0):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			i++
+		case c == quote:
+			quote = 0
+		}
+
+		if c != ' ' && c != '\t' {
+			prev = c
+		}
+	}
+
+	return line
+}
+
+func is_item(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func is_key(content string) bool {
+	if content[0] == '[' || content[0] == '{' {
+		return false
+	}
+
+	key, _ := split_key(content)
+	return key != ""
+}
+
+// split_key splits "key: value" into its key and value, the key is empty if
+// content is not a mapping entry.
+func split_key(content string) (string, string) {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case quote == 0 && (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == quote && i > 0:
+			quote = 0
+		case quote == 0 && c == ':' && (i+1 == len(content) || content[i+1] == ' '):
+			key := strings.TrimSpace(content[:i])
+			if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') {
+				key = key[1 : len(key)-1]
+			}
+			return key, strings.TrimSpace(content[i+1:])
+		}
+	}
+
+	return "", ""
+}
+
+// yaml_scalar parses a whole value, either a flow collection or a scalar.
+func yaml_scalar(s string) (any, error) {
+	v, rest, err := yaml_flow(s, false)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("unexpected %q after value", rest)
+	}
+
+	return v, nil
+}
+
+// yaml_flow parses the value at the start of s and returns the rest. Within
+// flow collections, plain scalars end at commas and closing brackets.
+func yaml_flow(s string, nested bool) (any, string, error) {
+	s = strings.TrimLeft(s, " ")
+	if s == "" {
+		return nil, "", nil
+	}
+
+	switch s[0] {
+	case '"':
+		end := closing_quote(s)
+		if end < 0 {
+			return nil, "", errors.New("unterminated string")
+		}
+
+		str, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, "", fmt.Errorf("bad string %s", s[:end+1])
+		}
+		return str, s[end+1:], nil
+
+	case '\'':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), s[i+1:], nil
+		}
+		return nil, "", errors.New("unterminated string")
+
+	case '[':
+		seq := []any{}
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " ")
+			if strings.HasPrefix(s, "]") {
+				return seq, s[1:], nil
+			}
+
+			v, rest, err := yaml_flow(s, true)
+			if err != nil {
+				return nil, "", err
+			}
+			seq = append(seq, v)
+
+			s = strings.TrimLeft(rest, " ")
+			switch {
+			case strings.HasPrefix(s, ","):
+				s = s[1:]
+			case !strings.HasPrefix(s, "]"):
+				return nil, "", errors.New("expected , or ] in flow sequence")
+			}
+		}
+
+	case '{':
+		m := map[string]any{}
+		s = s[1:]
+		for {
+			s = strings.TrimLeft(s, " ")
+			if strings.HasPrefix(s, "}") {
+				return m, s[1:], nil
+			}
+
+			key, rest, ok := strings.Cut(s, ":")
+			if !ok {
+				return nil, "", errors.New("expected key: value in flow mapping")
+			}
+
+			v, rest, err := yaml_flow(rest, true)
+			if err != nil {
+				return nil, "", err
+			}
+			m[strings.Trim(strings.TrimSpace(key), `"'`)] = v
+
+			s = strings.TrimLeft(rest, " ")
+			switch {
+			case strings.HasPrefix(s, ","):
+				s = s[1:]
+			case !strings.HasPrefix(s, "}"):
+				return nil, "", errors.New("expected , or } in flow mapping")
+			}
+		}
+	}
+
+	end := len(s)
+	if nested {
+		if i := strings.IndexAny(s, ",]}"); i >= 0 {
+			end = i
+		}
+	}
+
+	return plain_scalar(strings.TrimSpace(s[:end])), s[end:], nil
+}
+
+// plain_scalar resolves an unquoted scalar as in the YAML 1.2 core schema.
+func plain_scalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "_xXpP") {
+		return f
+	}
+
+	return s
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want map[string]any
+	}{
+		{
+			name: "empty",
+			doc:  "# nothing\n---\n",
+			want: map[string]any{},
+		},
+		{
+			name: "scalars",
+			doc: "a: 1\n" +
+				"b: 0.5\n" +
+				"c: true\n" +
+				"d: ~\n" +
+				"e: text\n" +
+				"f: \"quoted\\n\"\n" +
+				"g: 'it''s'\n" +
+				"h:\n",
+			want: map[string]any{
+				"a": int64(1), "b": 0.5, "c": true, "d": nil,
+				"e": "text", "f": "quoted\n", "g": "it's", "h": nil,
+			},
+		},
+		{
+			name: "nested blocks",
+			doc: "optimizer:\n" +
+				"  name: sgd\n" +
+				"  schedule:\n" +
+				"    kind: step\n" +
+				"    every: 2\n" +
+				"epochs: 3\n",
+			want: map[string]any{
+				"optimizer": map[string]any{
+					"name":     "sgd",
+					"schedule": map[string]any{"kind": "step", "every": int64(2)},
+				},
+				"epochs": int64(3),
+			},
+		},
+		{
+			name: "block sequences",
+			doc: "training:\n" +
+				"  - a.csv\n" +
+				"  - b.csv\n" +
+				"tests:\n" +
+				"- c.csv\n" +
+				"nested:\n" +
+				"  -\n" +
+				"    - 1\n" +
+				"    - 2\n",
+			want: map[string]any{
+				"training": []any{"a.csv", "b.csv"},
+				"tests":    []any{"c.csv"},
+				"nested":   []any{[]any{int64(1), int64(2)}},
+			},
+		},
+		{
+			name: "key items",
+			doc: "layers:\n" +
+				"  - size: 16\n" +
+				"    activation: relu\n" +
+				"  - size: 10\n",
+			want: map[string]any{
+				"layers": []any{
+					map[string]any{"size": int64(16), "activation": "relu"},
+					map[string]any{"size": int64(10)},
+				},
+			},
+		},
+		{
+			name: "flow collections",
+			doc: "dims: [784, 16, 10]\n" +
+				"optimizer: {name: sgd, learning_rate: 0.1}\n" +
+				"nested: [[1, 2], {a: 'x, y'}]\n" +
+				"empty: []\n",
+			want: map[string]any{
+				"dims":      []any{int64(784), int64(16), int64(10)},
+				"optimizer": map[string]any{"name": "sgd", "learning_rate": 0.1},
+				"nested":    []any{[]any{int64(1), int64(2)}, map[string]any{"a": "x, y"}},
+				"empty":     []any{},
+			},
+		},
+		{
+			name: "comments",
+			doc: "# leading\n" +
+				"training: [runs/a#1.csv] # trailing\n" +
+				"note: don't # apostrophes are not quotes\n" +
+				"quoted: 'a # b' # c\n" +
+				"escaped: \"a \\\" # b\"\n" +
+				"  # indented\n",
+			want: map[string]any{
+				"training": []any{"runs/a#1.csv"},
+				"note":     "don't",
+				"quoted":   "a # b",
+				"escaped":  "a \" # b",
+			},
+		},
+		{
+			name: "quoted keys",
+			doc:  "\"a: b\": 1\n'c': 2\n",
+			want: map[string]any{"a: b": int64(1), "c": int64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse_yaml([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("parse_yaml: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse_yaml:\ngot  %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"duplicate key", "a: 1\nb: 2\na: 3\n", "line 3: duplicate key \"a\""},
+		{"tab indentation", "a:\n\tb: 1\n", "line 2: tabs"},
+		{"not a key", "a: 1\njust text\n", "line 2: expected key: value"},
+		{"bad indentation", "a:\n    b: 1\n  c: 2\n", "line 3: unexpected indentation"},
+		{"unterminated string", "a: 'x\n", "line 1: unterminated string"},
+		{"unterminated flow", "a: [1, 2\n", "line 1: expected , or ]"},
+		{"trailing", "a: \"x\" y\n", "line 1: unexpected"},
+		{"not a mapping", "- 1\n- 2\n", "must be a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse_yaml([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parse_yaml: got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/mem"
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// DType is the type of the values stored in a binary dataset.
+type DType uint8
+
+const (
+	// DTypeUint8 stores values in [0, 1] as bytes, v * 255, rounded.
+	DTypeUint8 DType = 1
+
+	// DTypeFloat32 stores values as little endian IEEE 754 singles.
+	DTypeFloat32 DType = 2
+)
+
+// Size returns the number of bytes a single value takes.
+func (d DType) Size() int {
+	switch d {
+	case DTypeUint8:
+		return 1
+	case DTypeFloat32:
+		return 4
+	}
+
+	return 0
+}
+
+func (d DType) String() string {
+	switch d {
+	case DTypeUint8:
+		return "uint8"
+	case DTypeFloat32:
+		return "float32"
+	}
+
+	return fmt.Sprintf("DType(%d)", uint8(d))
+}
+
+// ParseDType returns the dtype with the given name, "uint8" or "float32".
+func ParseDType(name string) (DType, error) {
+	switch name {
+	case "uint8":
+		return DTypeUint8, nil
+	case "float32":
+		return DTypeFloat32, nil
+	}
+
+	return 0, fmt.Errorf("dataset: unknown dtype %q", name)
+}
+
+// binary_magic identifies a binary dataset file, binary_version is bumped on
+// any incompatible change to the layout. Version 1 files, which have no class
+// names, are still read.
+const (
+	binary_magic   = "NNDS"
+	binary_version = 2
+)
+
+// header is the fixed-size header of a binary dataset, all fields are little
+// endian. Since version 2, it is followed by the class names, a uint32 byte
+// length and the names separated by newlines. Then come Count records, each of
+// which is LabelSize label values followed by FeatureSize input values, all of
+// type DType.
+type header struct {
+	Magic       [4]byte
+	Version     uint16
+	DType       DType
+	_           uint8
+	Count       uint64
+	LabelSize   uint32
+	FeatureSize uint32
+}
+
+// header_size is the size of the encoded header in bytes.
+const header_size = 24
+
+// Limits of the headers that are accepted, so that corrupt or crafted ones
+// fail instead of exhausting the memory: max_sample_size is the largest label
+// or feature size, and max_names_size, the largest size of the class names.
+const (
+	max_sample_size = 1 << 24
+	max_names_size  = 1 << 24
+)
+
+// max_values is the most values, labels and features, that a binary dataset
+// loaded into memory may hold, 2 GiB worth of float64.
+const max_values = 1 << 28
+
+var (
+	ErrBinaryMagic   = errors.New("dataset: not a binary dataset")
+	ErrBinaryVersion = errors.New("dataset: unsupported binary dataset version")
+	ErrBinaryDType   = errors.New("dataset: unsupported binary dataset dtype")
+	ErrBinaryRange   = errors.New("dataset: value out of range [0, 1] for uint8")
+	ErrBinaryHeader  = errors.New("dataset: corrupt binary dataset header")
+)
+
+// LoadFromBinary loads a dataset stored with [StoreToBinary]. The samples
+// share a single backing buffer.
+//
+// The header is checked against the size of r, when it is an uncompressed
+// file, and the dataset may hold no more than max_values values, so that
+// corrupt headers fail rather than exhaust the memory.
+func LoadFromBinary(r io.Reader) (nn.Dataset, error) {
+	size, known := raw_size(r)
+
+	h, err := read_header(r)
+	if err != nil {
+		return nil, err
+	}
+
+	names, n, err := read_names(r, h)
+	if err != nil {
+		return nil, err
+	}
+
+	label_size := int(h.LabelSize)
+	values_size := int(h.FeatureSize)
+	record := label_size + values_size
+
+	if hi, lo := bits.Mul64(h.Count, uint64(record)); hi != 0 || lo > max_values {
+		return nil, fmt.Errorf("%w: %d records of %d values, over %d", ErrBinaryHeader, h.Count, record, max_values)
+	}
+
+	count := int(h.Count)
+	if want := header_size + int64(n) + int64(count*record*h.DType.Size()); known && size < want {
+		return nil, fmt.Errorf("%w: file of %d bytes, expected %d", ErrBinaryHeader, size, want)
+	}
+
+	buf := make([]float64, count*record)
+	if err := read_values(r, h.DType, buf); err != nil {
+		return nil, fmt.Errorf("dataset: read payload: %w", err)
+	}
+
+	samples := make([]nn.Sample, count)
+	for i := range samples {
+		samples[i] = nn.Sample{
+			Label:  nnmath.MakeVecData(label_size, mem.Take(&buf, label_size)),
+			Values: nnmath.MakeVecData(values_size, mem.Take(&buf, values_size)),
+		}
+	}
+
+	if names == nil {
+		return nn.Samples(samples), nil
+	}
+
+	return nn.WithClasses(nn.Samples(samples), nn.Classes{Count: label_size, Names: names}), nil
+}
+
+// StoreToBinary stores a dataset in the binary format, with values of the given
+// dtype. All samples must have the same dimensions as the first one. The
+// class names of the dataset, if any, are stored along.
+func StoreToBinary(w io.Writer, samples nn.Dataset, dtype DType) error {
+	if dtype.Size() == 0 {
+		return ErrBinaryDType
+	}
+
+	h := header{
+		Version: binary_version,
+		DType:   dtype,
+		Count:   uint64(samples.Len()),
+	}
+	copy(h.Magic[:], binary_magic)
+
+	if samples.Len() > 0 {
+		first := samples.Get(0)
+		h.LabelSize = uint32(first.Label.Size())
+		h.FeatureSize = uint32(first.Values.Size())
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := binary.Write(bw, binary.LittleEndian, &h); err != nil {
+		return fmt.Errorf("dataset: write header: %w", err)
+	}
+
+	names := strings.Join(nn.ClassesOf(samples).Names, "\n")
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(names))); err != nil {
+		return fmt.Errorf("dataset: write names: %w", err)
+	}
+	if _, err := bw.WriteString(names); err != nil {
+		return fmt.Errorf("dataset: write names: %w", err)
+	}
+
+	var buf []byte
+	for i, s := range nn.All(samples) {
+		if s.Label.Size() != int(h.LabelSize) || s.Values.Size() != int(h.FeatureSize) {
+			return fmt.Errorf("dataset: inconsistant sample size at row %d", i+1)
+		}
+
+		var err error
+		buf, err = append_values(buf[:0], dtype, s.Label.Data())
+		if err != nil {
+			return err
+		}
+
+		buf, err = append_values(buf, dtype, s.Values.Data())
+		if err != nil {
+			return err
+		}
+
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("dataset: write payload: %w", err)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// BestDType returns [DTypeUint8] if every value in the dataset can be stored
+// as such without loss, i.e., is a multiple of 1/255 in [0, 1], or
+// [DTypeFloat32] otherwise.
+func BestDType(samples nn.Dataset) DType {
+	lossless := func(values []float64) bool {
+		for _, v := range values {
+			if v < 0 || 1 < v || math.Abs(v*255-math.Round(v*255)) > 1e-6 {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, s := range nn.All(samples) {
+		if !lossless(s.Label.Data()) || !lossless(s.Values.Data()) {
+			return DTypeFloat32
+		}
+	}
+
+	return DTypeUint8
+}
+
+func read_header(r io.Reader) (*header, error) {
+	var h header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("dataset: read header: %w", err)
+	}
+
+	if string(h.Magic[:]) != binary_magic {
+		return nil, ErrBinaryMagic
+	}
+	if h.Version < 1 || binary_version < h.Version {
+		return nil, fmt.Errorf("%w: %d", ErrBinaryVersion, h.Version)
+	}
+	if h.DType.Size() == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBinaryDType, h.DType)
+	}
+
+	if h.LabelSize > max_sample_size || h.FeatureSize > max_sample_size {
+		return nil, fmt.Errorf("%w: sample size %d+%d over %d", ErrBinaryHeader, h.LabelSize, h.FeatureSize, max_sample_size)
+	}
+	if h.Count > 0 && (h.LabelSize == 0 || h.FeatureSize == 0) {
+		return nil, fmt.Errorf("%w: empty samples", ErrBinaryHeader)
+	}
+
+	// the payload, in bytes, must be addressable
+	record := uint64(h.LabelSize+h.FeatureSize) * uint64(h.DType.Size())
+	if hi, lo := bits.Mul64(h.Count, record); hi != 0 || lo > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: %d records of %d bytes", ErrBinaryHeader, h.Count, record)
+	}
+
+	return &h, nil
+}
+
+// read_names reads the class names that follow the header, if the version has
+// them, and returns them along with the number of bytes read. Names are nil if
+// there are none.
+func read_names(r io.Reader, h *header) ([]string, int, error) {
+	if h.Version < 2 {
+		return nil, 0, nil
+	}
+
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, 0, fmt.Errorf("dataset: read names: %w", err)
+	}
+
+	if size > max_names_size {
+		return nil, 0, fmt.Errorf("%w: class names of %d bytes", ErrBinaryHeader, size)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, 0, fmt.Errorf("dataset: read names: %w", err)
+	}
+
+	read := 4 + int(size)
+	if size == 0 {
+		return nil, read, nil
+	}
+
+	names := strings.Split(string(buf), "\n")
+	if len(names) != int(h.LabelSize) {
+		return nil, 0, fmt.Errorf("dataset: %d class names for labels of size %d", len(names), h.LabelSize)
+	}
+
+	return names, read, nil
+}
+
+// read_values reads and decodes len(dst) values of the given dtype.
+func read_values(r io.Reader, dtype DType, dst []float64) error {
+	size := dtype.Size()
+	chunk := make([]byte, 4096*size)
+
+	for len(dst) > 0 {
+		n := min(len(dst), len(chunk)/size)
+		if _, err := io.ReadFull(r, chunk[:n*size]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		decode_values(dst[:n], dtype, chunk[:n*size])
+		dst = dst[n:]
+	}
+
+	return nil
+}
+
+// decode_values decodes len(dst) values of the given dtype from src.
+func decode_values(dst []float64, dtype DType, src []byte) {
+	switch dtype {
+	case DTypeUint8:
+		for i := range dst {
+			dst[i] = float64(src[i]) / 255
+		}
+
+	case DTypeFloat32:
+		for i := range dst {
+			dst[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(src[4*i:])))
+		}
+	}
+}
+
+func append_values(buf []byte, dtype DType, values []float64) ([]byte, error) {
+	switch dtype {
+	case DTypeUint8:
+		for _, v := range values {
+			if v < 0 || 1 < v {
+				return nil, ErrBinaryRange
+			}
+			buf = append(buf, byte(math.Round(v*255)))
+		}
+
+	case DTypeFloat32:
+		for _, v := range values {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+		}
+	}
+
+	return buf, nil
+}
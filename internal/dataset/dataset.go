@@ -1,6 +1,7 @@
 package dataset
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -18,17 +19,48 @@ const (
 	cols = 1 + pixs
 )
 
-var Labels [10]nnmath.Vector
+// OneHot returns the one-hot label vectors of a label space with the given
+// number of classes, all sharing a single backing buffer.
+func OneHot(count int) []nnmath.Vector {
+	buf := make([]float64, count*count)
 
-func init() {
-	for i := range len(Labels) {
-		Labels[i] = nnmath.MakeVec(10)
-		Labels[i].Set(i, 0, 1)
+	labels := make([]nnmath.Vector, count)
+	for i := range count {
+		labels[i] = nnmath.MakeVecData(count, mem.Take(&buf, count))
+		labels[i].Set(i, 0, 1)
 	}
+
+	return labels
+}
+
+// one_hot assigns one-hot labels to the samples, given their class numbers.
+// If classes is not positive, the number of classes is inferred from the
+// largest label.
+func one_hot(samples []nn.Sample, labels []int, classes int) error {
+	if classes <= 0 {
+		for _, label := range labels {
+			classes = max(classes, label+1)
+		}
+	}
+
+	vectors := OneHot(classes)
+	for i, label := range labels {
+		if label < 0 || classes <= label {
+			return fmt.Errorf("dataset: label %d out of range at row %d", label, i+1)
+		}
+
+		samples[i].Label = vectors[label]
+	}
+
+	return nil
 }
 
-func LoadFromCSV(r io.Reader) ([]nn.Sample, error) {
+// LoadFromCSV loads a dataset from CSV, with a header row, where each row is
+// the class number followed by the pixels, in [0, 255]. If classes is not
+// positive, the number of classes is inferred from the largest label.
+func LoadFromCSV(r io.Reader, classes int) (nn.Dataset, error) {
 	var dataset []nn.Sample
+	var labels []int
 
 	reader := csv.NewReader(r)
 	reader.ReuseRecord = true
@@ -56,12 +88,8 @@ func LoadFromCSV(r io.Reader) ([]nn.Sample, error) {
 		if err != nil {
 			return nil, fmt.Errorf("dataset: could not parse label: %w", err)
 		}
-		if label < 0 || 10 <= label {
-			return nil, fmt.Errorf("dataset: label out of range")
-		}
 
 		sample := nn.Sample{
-			Label:  Labels[label],
 			Values: nnmath.MakeVec(pixs),
 		}
 
@@ -70,32 +98,57 @@ func LoadFromCSV(r io.Reader) ([]nn.Sample, error) {
 			if err != nil {
 				return nil, fmt.Errorf("dataset: could not parse pixel value: %w", err)
 			}
-			if label < 0 || 255 < label {
-				return nil, fmt.Errorf("dataset: label out of range")
+			if pixel < 0 || 255 < pixel {
+				return nil, fmt.Errorf("dataset: pixel value out of range")
 			}
 
 			sample.Values.Set(i, 0, float64(pixel)/255)
 		}
 
 		dataset = append(dataset, sample)
+		labels = append(labels, label)
 	}
 
-	return dataset, nil
+	if err := one_hot(dataset, labels, classes); err != nil {
+		return nil, err
+	}
+
+	return nn.Samples(dataset), nil
 }
 
-func LoadFromJSON(r io.Reader) ([]nn.Sample, error) {
+// LoadFromJSON loads a dataset from JSON, either an array of samples or an
+// object holding the names of the classes and the array of samples, as stored
+// by [StoreToJSON].
+func LoadFromJSON(r io.Reader) (nn.Dataset, error) {
+	br := bufio.NewReader(r)
+
 	var s []sample
-	if err := json.NewDecoder(r).Decode(&s); err != nil {
-		return nil, fmt.Errorf("dataset: parse JSON file: %w", err)
+	var names []string
+
+	if first, err := peek_non_space(br); err == nil && first == '{' {
+		var o object
+		if err := json.NewDecoder(br).Decode(&o); err != nil {
+			return nil, fmt.Errorf("dataset: parse JSON file: %w", err)
+		}
+
+		s, names = o.Samples, o.Classes
+	} else {
+		if err := json.NewDecoder(br).Decode(&s); err != nil {
+			return nil, fmt.Errorf("dataset: parse JSON file: %w", err)
+		}
 	}
 
 	if len(s) == 0 {
-		return []nn.Sample{}, nil
+		return nn.Samples{}, nil
 	}
 
 	label_size := len(s[0].Label)
 	values_size := len(s[0].Values)
 
+	if names != nil && len(names) != label_size {
+		return nil, fmt.Errorf("dataset: expected %d class names, got %d", label_size, len(names))
+	}
+
 	samples := make([]nn.Sample, len(s))
 	buf := make([]float64, (label_size+values_size)*len(s))
 
@@ -105,7 +158,7 @@ func LoadFromJSON(r io.Reader) ([]nn.Sample, error) {
 		}
 
 		if len(s.Values) != values_size {
-			return nil, fmt.Errorf("dataset: inconsistant values size at row %d: expected %d, got %d", i+1, label_size, len(s.Label))
+			return nil, fmt.Errorf("dataset: inconsistant values size at row %d: expected %d, got %d", i+1, values_size, len(s.Values))
 		}
 
 		label := mem.Take(&buf, label_size)
@@ -120,26 +173,58 @@ func LoadFromJSON(r io.Reader) ([]nn.Sample, error) {
 		}
 	}
 
-	return samples, nil
+	if names != nil {
+		return nn.WithClasses(nn.Samples(samples), nn.Classes{Count: label_size, Names: names}), nil
+	}
+
+	return nn.Samples(samples), nil
 }
 
-func StoreToJSON(w io.Writer, samples []nn.Sample) error {
-	ss := make([]sample, 0, len(samples))
-	for _, s := range samples {
+// StoreToJSON stores a dataset as JSON, an array of samples, or, if the
+// dataset has named classes, an object holding the names and the array.
+func StoreToJSON(w io.Writer, samples nn.Dataset) error {
+	ss := make([]sample, 0, samples.Len())
+	for _, s := range nn.All(samples) {
 		ss = append(ss, sample{
 			Label:  s.Label.Data(),
 			Values: s.Values.Data(),
 		})
 	}
 
-	if err := json.NewEncoder(w).Encode(ss); err != nil {
+	var v any = ss
+	if names := nn.ClassesOf(samples).Names; names != nil {
+		v = object{Classes: names, Samples: ss}
+	}
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
 		return fmt.Errorf("dataset: encode JSON: %w", err)
 	}
 
 	return nil
 }
 
+type object struct {
+	Classes []string `json:"classes"`
+	Samples []sample `json:"samples"`
+}
+
 type sample struct {
 	Label  mem.Float64Slice `json:"label"`
 	Values mem.Float64Slice `json:"values"`
 }
+
+func peek_non_space(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b, r.UnreadByte()
+	}
+}
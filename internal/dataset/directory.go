@@ -0,0 +1,113 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alan-b-lima/nn-digits/internal/digits"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// LoadFromDirectory loads a dataset from a directory tree of images, laid out
+// as root/<label>/<image>, where <image> is a PNG, JPEG or GIF file. Images
+// are converted with [digits.FromImage] and normalized with
+// [digits.Preprocess], other files are ignored.
+//
+// If every <label> is a number, it is the class number, and, if classes is not
+// positive, the number of classes is inferred from the largest label.
+// Otherwise, the labels are the names of the classes, in lexical order, and
+// classes, if positive, must match their number.
+func LoadFromDirectory(root string, classes int) (nn.Dataset, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	numeric := true
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dirs = append(dirs, entry.Name())
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			numeric = false
+		}
+	}
+
+	if !numeric && classes > 0 && classes != len(dirs) {
+		return nil, fmt.Errorf("dataset: expected %d classes, found %d", classes, len(dirs))
+	}
+
+	var samples []nn.Sample
+	var labels []int
+
+	for i, name := range dirs {
+		label := i
+		if numeric {
+			label, _ = strconv.Atoi(name)
+		}
+
+		dir := filepath.Join(root, name)
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !is_image(file.Name()) {
+				continue
+			}
+
+			path := filepath.Join(dir, file.Name())
+			req, err := load_image(path)
+			if err != nil {
+				return nil, fmt.Errorf("dataset: %s: %w", path, err)
+			}
+
+			req = digits.Preprocess(req, false)
+			samples = append(samples, nn.Sample{
+				Values: nnmath.MakeVecData(len(req), req[:]),
+			})
+			labels = append(labels, label)
+		}
+	}
+
+	if numeric {
+		if err := one_hot(samples, labels, classes); err != nil {
+			return nil, err
+		}
+
+		return nn.Samples(samples), nil
+	}
+
+	if err := one_hot(samples, labels, len(dirs)); err != nil {
+		return nil, err
+	}
+
+	return nn.WithClasses(nn.Samples(samples), nn.Classes{Count: len(dirs), Names: dirs}), nil
+}
+
+func is_image(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+
+	return false
+}
+
+func load_image(path string) (*digits.Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return digits.DecodeImage(f)
+}
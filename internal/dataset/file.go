@@ -0,0 +1,187 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// Format is a file format a dataset may be stored in.
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatCSV
+	FormatJSON
+	FormatIDX
+	FormatBinary
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "CSV"
+	case FormatJSON:
+		return "JSON"
+	case FormatIDX:
+		return "IDX"
+	case FormatBinary:
+		return "binary"
+	}
+
+	return "unknown"
+}
+
+// DetectFormat guesses the format of a dataset file from its name and, if
+// that is not enough, from the first bytes of its decompressed content.
+func DetectFormat(name string, header []byte) Format {
+	name = strings.TrimSuffix(strings.ToLower(name), ".gz")
+
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".json"):
+		return FormatJSON
+	case strings.HasSuffix(name, "ubyte"):
+		return FormatIDX
+	case strings.HasSuffix(name, ".nnds"):
+		return FormatBinary
+	}
+
+	header = bytes.TrimLeft(header, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(header, []byte(binary_magic)):
+		return FormatBinary
+	case len(header) >= 3 && header[0] == 0 && header[1] == 0 && header[2] == idx_ubyte:
+		return FormatIDX
+	case len(header) >= 1 && (header[0] == '[' || header[0] == '{'):
+		return FormatJSON
+	case len(header) >= 1:
+		return FormatCSV
+	}
+
+	return FormatUnknown
+}
+
+var reIDXImages = regexp.MustCompile(`images([.\-])idx3`)
+
+// LabelsPath returns the conventional path of the IDX labels file that pairs
+// with the given IDX images file, e.g., train-labels-idx1-ubyte for
+// train-images-idx3-ubyte, or an empty string if there is none.
+func LabelsPath(images string) string {
+	dir, base := filepath.Split(images)
+	if !reIDXImages.MatchString(base) {
+		return ""
+	}
+
+	return dir + reIDXImages.ReplaceAllString(base, "labels${1}idx1")
+}
+
+// LoadFile loads a dataset from the file at path, detecting its format. IDX
+// datasets also need the labels file, which is either given as the first
+// extra path or derived with [LabelsPath]. Files may be gzip compressed.
+// Directories are loaded with [LoadFromDirectory].
+//
+// Formats whose labels are class numbers are one-hot encoded with the given
+// number of classes, or, if it is not positive, with as many as inferred from
+// the largest label.
+func LoadFile(path string, classes int, extra ...string) (nn.Dataset, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return LoadFromDirectory(path, classes)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: decompress: %w", err)
+	}
+
+	header, _ := r.Peek(4)
+
+	switch format := DetectFormat(path, header); format {
+	case FormatCSV:
+		return LoadFromCSV(r, classes)
+
+	case FormatJSON:
+		return LoadFromJSON(r)
+
+	case FormatBinary:
+		return LoadFromBinary(r)
+
+	case FormatIDX:
+		labels_path := LabelsPath(path)
+		if len(extra) > 0 {
+			labels_path = extra[0]
+		}
+		if labels_path == "" {
+			return nil, fmt.Errorf("dataset: no labels file for %q", path)
+		}
+
+		labels, err := os.Open(labels_path)
+		if err != nil {
+			return nil, err
+		}
+		defer labels.Close()
+
+		// rewinds the images, so that the size of their file is known
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		return LoadFromIDX(f, labels, classes)
+
+	default:
+		return nil, fmt.Errorf("dataset: unknown format of %q", path)
+	}
+}
+
+// OpenFile opens the dataset file at path, as [LoadFile] does, except that
+// uncompressed binary datasets are memory-mapped with [OpenMapped] rather than
+// loaded.
+func OpenFile(path string, classes int, extra ...string) (nn.Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	n, _ := io.ReadFull(f, header[:])
+	f.Close()
+
+	if string(header[:n]) == binary_magic {
+		return OpenMapped(path)
+	}
+
+	return LoadFile(path, classes, extra...)
+}
+
+// StoreFile stores a dataset onto the file at path, as JSON if its extension
+// is .json, or in the binary format with the given dtype otherwise.
+func StoreFile(path string, samples nn.Dataset, dtype DType) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return StoreToJSON(f, samples)
+	}
+
+	return StoreToBinary(f, samples, dtype)
+}
@@ -0,0 +1,212 @@
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/bits"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/mem"
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// IDX type codes, only unsigned bytes are supported.
+const idx_ubyte = 0x08
+
+// chunk_values is about how many values are read, and allocated, at once when
+// loading an IDX dataset, so that a header claiming more images than there are
+// fails on a short read rather than on a huge allocation.
+const chunk_values = 1 << 20
+
+var (
+	ErrIDXType   = errors.New("dataset: only unsigned byte IDX files are supported")
+	ErrIDXHeader = errors.New("dataset: corrupt IDX header")
+)
+
+// LoadFromIDX loads a dataset from a pair of IDX files, as distributed by the
+// MNIST database: images, an idx3-ubyte file, and labels, an idx1-ubyte file.
+// Either of them may be gzip compressed. If classes is not positive, the
+// number of classes is inferred from the largest label.
+//
+// The headers are checked against the sizes of the files, when they are
+// uncompressed files, and samples are read in chunks, so that corrupt headers
+// fail rather than exhaust the memory.
+func LoadFromIDX(images, labels io.Reader, classes int) (nn.Dataset, error) {
+	isize, iknown := raw_size(images)
+	lsize, lknown := raw_size(labels)
+
+	images, err := decompress(images)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: decompress images: %w", err)
+	}
+
+	labels, err = decompress(labels)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: decompress labels: %w", err)
+	}
+
+	idims, err := read_idx_header(images)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: read images header: %w", err)
+	}
+	if len(idims) < 2 {
+		return nil, fmt.Errorf("dataset: images must have at least 2 dimensions, found %d", len(idims))
+	}
+
+	ldims, err := read_idx_header(labels)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: read labels header: %w", err)
+	}
+	if len(ldims) != 1 {
+		return nil, fmt.Errorf("dataset: labels must have 1 dimension, found %d", len(ldims))
+	}
+
+	count := idims[0]
+	if count != ldims[0] {
+		return nil, fmt.Errorf("dataset: found %d images but %d labels", count, ldims[0])
+	}
+
+	features := 1
+	for _, dim := range idims[1:] {
+		if dim == 0 || features > max_sample_size/dim {
+			return nil, fmt.Errorf("%w: image dimensions %v", ErrIDXHeader, idims[1:])
+		}
+		features *= dim
+	}
+
+	if hi, lo := bits.Mul64(uint64(count), uint64(features)); hi != 0 || lo > math.MaxInt {
+		return nil, fmt.Errorf("%w: %d images of %d pixels", ErrIDXHeader, count, features)
+	}
+	if iknown && isize < idx_size(idims) {
+		return nil, fmt.Errorf("%w: images file of %d bytes, expected %d", ErrIDXHeader, isize, idx_size(idims))
+	}
+	if lknown && lsize < idx_size(ldims) {
+		return nil, fmt.Errorf("%w: labels file of %d bytes, expected %d", ErrIDXHeader, lsize, idx_size(ldims))
+	}
+
+	per_chunk := max(chunk_values/features, 1)
+
+	samples := make([]nn.Sample, 0, min(count, per_chunk))
+	label_ints := make([]int, 0, min(count, per_chunk))
+	pixels := make([]byte, features)
+
+	for len(samples) < count {
+		n := min(count-len(samples), per_chunk)
+
+		label_buf := make([]byte, n)
+		if _, err := io.ReadFull(labels, label_buf); err != nil {
+			return nil, fmt.Errorf("dataset: read labels: %w", err)
+		}
+
+		buf := make([]float64, n*features)
+		for _, label := range label_buf {
+			if _, err := io.ReadFull(images, pixels); err != nil {
+				return nil, fmt.Errorf("dataset: read image %d: %w", len(samples), err)
+			}
+
+			values := mem.Take(&buf, features)
+			for j, pixel := range pixels {
+				values[j] = float64(pixel) / 255
+			}
+
+			samples = append(samples, nn.Sample{Values: nnmath.MakeVecData(features, values)})
+			label_ints = append(label_ints, int(label))
+		}
+	}
+
+	if err := one_hot(samples, label_ints, classes); err != nil {
+		return nil, err
+	}
+
+	return nn.Samples(samples), nil
+}
+
+// read_idx_header reads the magic number and the dimensions of an IDX file.
+func read_idx_header(r io.Reader) ([]int, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+
+	if magic[0] != 0 || magic[1] != 0 {
+		return nil, fmt.Errorf("bad magic number %x", magic)
+	}
+	if magic[2] != idx_ubyte {
+		return nil, ErrIDXType
+	}
+
+	dims := make([]int, magic[3])
+	for i := range dims {
+		var dim uint32
+		if err := binary.Read(r, binary.BigEndian, &dim); err != nil {
+			return nil, err
+		}
+
+		dims[i] = int(dim)
+	}
+
+	return dims, nil
+}
+
+// idx_size returns the size in bytes of an unsigned byte IDX file with the
+// given dimensions, which must not overflow.
+func idx_size(dims []int) int64 {
+	size := int64(1)
+	for _, dim := range dims {
+		size *= int64(dim)
+	}
+
+	return 4 + 4*int64(len(dims)) + size
+}
+
+// raw_size returns the size of r, if it is a regular file, read from its
+// start, that is not gzip compressed.
+func raw_size(r io.Reader) (int64, bool) {
+	f, ok := r.(interface {
+		io.ReaderAt
+		Stat() (fs.FileInfo, error)
+	})
+	if !ok {
+		return 0, false
+	}
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+
+	var magic [2]byte
+	if n, _ := f.ReadAt(magic[:], 0); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return 0, false
+	}
+
+	return info.Size(), true
+}
+
+// decompress returns a reader that transparently decompresses r, if it is
+// gzip compressed, or reads it as is otherwise.
+func decompress(r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		return bufio.NewReader(gr), nil
+	}
+
+	return br, nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package dataset
+
+import (
+	"io"
+	"os"
+)
+
+func mmap(f *os.File) ([]byte, error) {
+	return io.ReadAll(f)
+}
+
+func munmap([]byte) error {
+	return nil
+}
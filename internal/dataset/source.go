@@ -0,0 +1,179 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/mem"
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// records describes the layout of the records of a binary dataset.
+type records struct {
+	start       int64
+	count       int
+	label_size  int
+	values_size int
+	dtype       DType
+	names       []string
+}
+
+// read_records reads the header and class names of a binary dataset.
+func read_records(r io.Reader) (records, error) {
+	h, err := read_header(r)
+	if err != nil {
+		return records{}, err
+	}
+
+	names, n, err := read_names(r, h)
+	if err != nil {
+		return records{}, err
+	}
+
+	return records{
+		start:       header_size + int64(n),
+		count:       int(h.Count),
+		label_size:  int(h.LabelSize),
+		values_size: int(h.FeatureSize),
+		dtype:       h.DType,
+		names:       names,
+	}, nil
+}
+
+// size returns the size of a single record in bytes.
+func (r records) size() int {
+	return (r.label_size + r.values_size) * r.dtype.Size()
+}
+
+// offset returns the offset of the i-th record from the start of the file.
+func (r records) offset(i int) int64 {
+	return r.start + int64(i)*int64(r.size())
+}
+
+// decode decodes a record onto a newly allocated sample.
+func (r records) decode(record []byte) nn.Sample {
+	buf := make([]float64, r.label_size+r.values_size)
+	decode_values(buf, r.dtype, record)
+
+	return nn.Sample{
+		Label:  nnmath.MakeVecData(r.label_size, mem.Take(&buf, r.label_size)),
+		Values: nnmath.MakeVecData(r.values_size, mem.Take(&buf, r.values_size)),
+	}
+}
+
+// Classes returns the number of classes and their names, if stored.
+func (r records) Classes() nn.Classes {
+	return nn.Classes{Count: r.label_size, Names: r.names}
+}
+
+func (r records) check(i int) {
+	if i < 0 || r.count <= i {
+		panic(fmt.Sprintf("index out of range [%d] with length %d", i, r.count))
+	}
+}
+
+// Mapped is a binary dataset file mapped into memory, samples are decoded on
+// each call of Get. On platforms without memory mapping, the file is read
+// into memory as a whole instead.
+//
+// Mapped must be closed once no longer used, any use afterwards panics.
+type Mapped struct {
+	records
+	data []byte
+}
+
+var _ nn.Labeled = &Mapped{}
+
+// OpenMapped maps the binary dataset file at path into memory.
+func OpenMapped(path string) (*Mapped, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := mmap(f)
+	if err != nil {
+		return nil, fmt.Errorf("dataset: map file: %w", err)
+	}
+
+	records, err := read_records(bytes.NewReader(data))
+	if err != nil {
+		munmap(data)
+		return nil, err
+	}
+
+	m := Mapped{records: records, data: data}
+	if int64(len(data)) < m.offset(m.count) {
+		munmap(data)
+		return nil, fmt.Errorf("dataset: truncated file: %w", io.ErrUnexpectedEOF)
+	}
+
+	return &m, nil
+}
+
+func (m *Mapped) Len() int {
+	return m.count
+}
+
+func (m *Mapped) Get(i int) nn.Sample {
+	m.check(i)
+
+	offset := m.offset(i)
+	return m.decode(m.data[offset : offset+int64(m.size())])
+}
+
+// Close unmaps the file.
+func (m *Mapped) Close() error {
+	data := m.data
+	m.data = nil
+
+	return munmap(data)
+}
+
+// Lazy is a binary dataset read from an [io.ReaderAt], such as an [os.File],
+// a sample is read and decoded on each call of Get.
+//
+// The size of the data is checked against the header when opened, so Get only
+// panics if the underlying reader fails, or the data shrinks, afterwards.
+type Lazy struct {
+	records
+	r io.ReaderAt
+}
+
+var _ nn.Labeled = &Lazy{}
+
+// OpenLazy reads the header of the binary dataset in r, whose data is size
+// bytes long, as [io.NewSectionReader] takes it. The reader must stay valid
+// for as long as the dataset is used.
+func OpenLazy(r io.ReaderAt, size int64) (*Lazy, error) {
+	records, err := read_records(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	l := Lazy{records: records, r: r}
+	if size < l.offset(l.count) {
+		return nil, fmt.Errorf("dataset: truncated file: %w", io.ErrUnexpectedEOF)
+	}
+
+	return &l, nil
+}
+
+func (l *Lazy) Len() int {
+	return l.count
+}
+
+func (l *Lazy) Get(i int) nn.Sample {
+	l.check(i)
+
+	record := make([]byte, l.size())
+	if _, err := l.r.ReadAt(record, l.offset(i)); err != nil {
+		panic(fmt.Sprintf("dataset: read record %d: %v", i, err))
+	}
+
+	return l.decode(record)
+}
@@ -0,0 +1,99 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"slices"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// Summary holds descriptive statistics of a dataset, as computed by
+// [Summarize].
+type Summary struct {
+	// Count is the number of samples.
+	Count int
+
+	// Min and Max are the smallest and largest input values across all
+	// samples.
+	Min, Max float64
+
+	// Duplicates is the number of samples whose input values are exactly
+	// the same as the ones of a previous sample.
+	Duplicates int
+
+	// Labels is the number of samples of each class, i.e., with the
+	// largest label value at that index.
+	Labels []int
+
+	// Mean and Variance are the per-feature mean and variance of the input
+	// values.
+	Mean     []float64
+	Variance []float64
+}
+
+// Summarize computes the descriptive statistics of a dataset. All samples are
+// expected to have the same dimensions as the first one.
+func Summarize(samples nn.Dataset) *Summary {
+	s := Summary{
+		Count: samples.Len(),
+		Min:   math.Inf(1),
+		Max:   math.Inf(-1),
+	}
+
+	if samples.Len() == 0 {
+		s.Min, s.Max = 0, 0
+		return &s
+	}
+
+	first := samples.Get(0)
+	features := first.Values.Size()
+	s.Labels = make([]int, first.Label.Size())
+	s.Mean = make([]float64, features)
+	s.Variance = make([]float64, features)
+
+	seed := maphash.MakeSeed()
+	seen := make(map[uint64][]int)
+
+	var buf []byte
+	for i, sample := range nn.All(samples) {
+		values := sample.Values.Data()
+
+		for j, v := range values {
+			s.Min = min(s.Min, v)
+			s.Max = max(s.Max, v)
+
+			s.Mean[j] += v
+			s.Variance[j] += v * v
+		}
+
+		if label := nn.IndexOfMax(sample.Label.Data()); label >= 0 {
+			s.Labels[label]++
+		}
+
+		buf = buf[:0]
+		for _, v := range values {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+
+		hash := maphash.Bytes(seed, buf)
+		duplicate := slices.ContainsFunc(seen[hash], func(j int) bool {
+			return slices.Equal(samples.Get(j).Values.Data(), values)
+		})
+
+		if duplicate {
+			s.Duplicates++
+		} else {
+			seen[hash] = append(seen[hash], i)
+		}
+	}
+
+	n := float64(samples.Len())
+	for j := range features {
+		s.Mean[j] /= n
+		s.Variance[j] = max(s.Variance[j]/n-s.Mean[j]*s.Mean[j], 0)
+	}
+
+	return &s
+}
@@ -0,0 +1,87 @@
+package digits
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// DecodeImage decodes a PNG, JPEG or GIF image into a request, see
+// [FromImage].
+func DecodeImage(r io.Reader) (*Request, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("digits: decode image: %w", err)
+	}
+
+	return FromImage(img), nil
+}
+
+// FromImage converts an image into a request. The image is converted to
+// grayscale, over a white background if translucent, inverted if it is dark
+// ink on light paper, since requests are light ink on black, and resized,
+// preserving the aspect ratio, to fit the request, with anti-aliasing.
+func FromImage(img image.Image) *Request {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var req Request
+	if w == 0 || h == 0 {
+		return &req
+	}
+
+	gray := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+
+			// colors are alpha-premultiplied, so adding the missing
+			// alpha composes them over white
+			lum := (.299*float64(r) + .587*float64(g) + .114*float64(b) + float64(0xffff-a)) / 0xffff
+			gray[y*w+x] = min(max(lum, 0), 1)
+		}
+	}
+
+	if border_mean(gray, w, h) > .5 {
+		for i := range gray {
+			gray[i] = 1 - gray[i]
+		}
+	}
+
+	scale := float64(max(w, h)) / Side
+	rw := min(max(int(math.Round(float64(w)/scale)), 1), Side)
+	rh := min(max(int(math.Round(float64(h)/scale)), 1), Side)
+	ox, oy := (Side-rw)/2, (Side-rh)/2
+
+	for y := range rh {
+		for x := range rw {
+			req[(y+oy)*Side+x+ox] = area(gray, w, 0, 0, w, h,
+				float64(x)*scale, float64(y)*scale, float64(x+1)*scale, float64(y+1)*scale,
+			)
+		}
+	}
+
+	return &req
+}
+
+// border_mean returns the mean value of the outermost pixels of an image,
+// which tells the background apart from the ink.
+func border_mean(img []float64, w, h int) float64 {
+	var sum float64
+	var n int
+	for y := range h {
+		for x := range w {
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				sum += img[y*w+x]
+				n++
+			}
+		}
+	}
+
+	return sum / float64(n)
+}
@@ -0,0 +1,168 @@
+package digits
+
+import "math"
+
+const (
+	// Side is the side of the square images a [Request] holds.
+	Side = 28
+
+	// Box is the side of the box digits are fit into, as in MNIST.
+	Box = 20
+)
+
+// Preprocess normalizes a drawing the way the MNIST digits were: the digit is
+// cropped to its bounding box, resized, preserving the aspect ratio, to fit a
+// 20x20 box with anti-aliasing, and centered by its center of mass. If deskew
+// is set, the digit is also sheared upright beforehand.
+//
+// Preprocess returns a new request, an empty drawing is returned as is.
+func Preprocess(req *Request, deskew bool) *Request {
+	img := *req
+	if deskew {
+		img = Deskew(&img)
+	}
+
+	x0, y0, x1, y1, ok := bounds(&img)
+	if !ok {
+		return &img
+	}
+
+	cw, ch := x1-x0, y1-y0
+	scale := float64(max(cw, ch)) / Box
+
+	w := min(max(int(math.Round(float64(cw)/scale)), 1), Box)
+	h := min(max(int(math.Round(float64(ch)/scale)), 1), Box)
+
+	small := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			small[y*w+x] = area(img[:], Side, x0, y0, x1, y1,
+				float64(x)*scale, float64(y)*scale, float64(x+1)*scale, float64(y+1)*scale,
+			)
+		}
+	}
+
+	var mass, mx, my float64
+	for y := range h {
+		for x := range w {
+			v := small[y*w+x]
+			mass += v
+			mx += v * float64(x)
+			my += v * float64(y)
+		}
+	}
+
+	cx, cy := float64(w-1)/2, float64(h-1)/2
+	if mass > 0 {
+		cx, cy = mx/mass, my/mass
+	}
+
+	center := float64(Side-1) / 2
+	ox := int(math.Round(center - cx))
+	oy := int(math.Round(center - cy))
+
+	var res Request
+	for y := range h {
+		for x := range w {
+			if tx, ty := x+ox, y+oy; 0 <= tx && tx < Side && 0 <= ty && ty < Side {
+				res[ty*Side+tx] = small[y*w+x]
+			}
+		}
+	}
+
+	return &res
+}
+
+// Deskew shears the digit horizontally so that its principal axis becomes
+// vertical, using the second order moments of the image.
+func Deskew(req *Request) Request {
+	var mass, mx, my float64
+	for y := range Side {
+		for x := range Side {
+			v := req[y*Side+x]
+			mass += v
+			mx += v * float64(x)
+			my += v * float64(y)
+		}
+	}
+	if mass == 0 {
+		return *req
+	}
+	mx, my = mx/mass, my/mass
+
+	var mu11, mu02 float64
+	for y := range Side {
+		for x := range Side {
+			v := req[y*Side+x]
+			dx, dy := float64(x)-mx, float64(y)-my
+			mu11 += v * dx * dy
+			mu02 += v * dy * dy
+		}
+	}
+	if mu02 < 1e-2 {
+		return *req
+	}
+	skew := mu11 / mu02
+
+	var res Request
+	for y := range Side {
+		for x := range Side {
+			sx := float64(x) + skew*(float64(y)-my)
+			res[y*Side+x] = bilinear(req, sx, float64(y))
+		}
+	}
+
+	return res
+}
+
+// bounds returns the bounding box [x0, x1) x [y0, y1) of the non-zero pixels.
+func bounds(req *Request) (x0, y0, x1, y1 int, ok bool) {
+	x0, y0, x1, y1 = Side, Side, 0, 0
+	for y := range Side {
+		for x := range Side {
+			if req[y*Side+x] > 0 {
+				x0, y0 = min(x0, x), min(y0, y)
+				x1, y1 = max(x1, x+1), max(y1, y+1)
+			}
+		}
+	}
+
+	return x0, y0, x1, y1, x0 < x1
+}
+
+// area returns the mean value of the region [ax, bx) x [ay, by), relative to
+// the crop [x0, x1) x [y0, y1) of an image laid out row by row with the given
+// stride, weighting each pixel by how much of it is covered, which works as an
+// anti-aliasing box filter.
+func area(img []float64, stride, x0, y0, x1, y1 int, ax, ay, bx, by float64) float64 {
+	var sum float64
+	for y := int(ay); y < int(math.Ceil(by)) && y0+y < y1; y++ {
+		hy := min(by, float64(y+1)) - max(ay, float64(y))
+		for x := int(ax); x < int(math.Ceil(bx)) && x0+x < x1; x++ {
+			hx := min(bx, float64(x+1)) - max(ax, float64(x))
+			sum += hx * hy * img[(y0+y)*stride+x0+x]
+		}
+	}
+
+	// clamped, as rounding may push the mean of ones past one
+	return min(sum/((bx-ax)*(by-ay)), 1)
+}
+
+// bilinear samples the image at the real coordinates (x, y), pixels outside
+// of the image are 0.
+func bilinear(req *Request, x, y float64) float64 {
+	at := func(x, y int) float64 {
+		if x < 0 || Side <= x || y < 0 || Side <= y {
+			return 0
+		}
+		return req[y*Side+x]
+	}
+
+	ix, iy := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(ix), y-float64(iy)
+
+	top := at(ix, iy)*(1-fx) + at(ix+1, iy)*fx
+	bottom := at(ix, iy+1)*(1-fx) + at(ix+1, iy+1)*fx
+
+	return top*(1-fy) + bottom*fy
+}
@@ -7,33 +7,56 @@ import (
 
 type (
 	Classifier interface {
-		Classify(*Request) (*Result, error)
+		Classify(*Request) (Result, error)
 	}
 )
 
 type (
-	Request [28 * 28]float64
-	Result  [10]float64
+	Request [Side * Side]float64
+	Result  []float64
 )
 
 type classifier struct {
 	nn *nn.NeuralNetwork
+
+	preprocess bool
+	deskew     bool
 }
 
 var _ Classifier = &classifier{}
 
-func NewClassifier(nn *nn.NeuralNetwork) Classifier {
-	return &classifier{nn: nn}
+// Option configures a classifier created by [NewClassifier].
+type Option func(*classifier)
+
+// WithoutPreprocessing feeds requests to the network as they are, rather than
+// normalizing them with [Preprocess] first.
+func WithoutPreprocessing() Option {
+	return func(c *classifier) { c.preprocess = false }
 }
 
-func (s *classifier) Classify(req *Request) (*Result, error) {
-	mat := nnmath.MakeVecData(len(req), req[:])
-	res := s.nn.FeedForward(mat)
+// WithDeskew makes the preprocessing also deskew requests, see [Preprocess].
+func WithDeskew() Option {
+	return func(c *classifier) { c.deskew = true }
+}
+
+// NewClassifier returns a classifier backed by the given network. By default,
+// requests are normalized with [Preprocess], without deskewing.
+func NewClassifier(nn *nn.NeuralNetwork, opts ...Option) Classifier {
+	c := classifier{nn: nn, preprocess: true}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
 
-	data := res.Data()
-	if len(data) != 10 {
-		return &Result{}, nil
+func (s *classifier) Classify(req *Request) (Result, error) {
+	if s.preprocess {
+		req = Preprocess(req, s.deskew)
 	}
 
-	return (*Result)(data), nil
+	mat := nnmath.MakeVecData(len(req), req[:])
+	res := s.nn.FeedForward(mat)
+
+	return Result(res.Data()), nil
 }
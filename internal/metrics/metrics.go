@@ -0,0 +1,203 @@
+// Package metrics logs the metrics of training runs as JSON lines, one object
+// per evaluation, and reads them back for later analysis.
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// Entry is a line of a metrics log, the metrics of a network evaluated at a
+// given cycle of its training. Metrics of datasets that were not evaluated,
+// or that are not finite, are nil.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Context string    `json:"context,omitempty"`
+
+	Cycle        int     `json:"cycle"`
+	BatchSize    int     `json:"batch_size"`
+	LearningRate float64 `json:"learning_rate"`
+
+	TrainingCost       *float64 `json:"training_cost,omitempty"`
+	TrainingAccuracy   *float64 `json:"training_accuracy,omitempty"`
+	TestCost           *float64 `json:"test_cost,omitempty"`
+	TestAccuracy       *float64 `json:"test_accuracy,omitempty"`
+	ValidationCost     *float64 `json:"validation_cost,omitempty"`
+	ValidationAccuracy *float64 `json:"validation_accuracy,omitempty"`
+
+	// GradientNorm is the mean norm of the gradients of the batches since the
+	// previous entry.
+	GradientNorm *float64 `json:"gradient_norm,omitempty"`
+
+	// WallTime is the number of seconds since the logger was created.
+	WallTime float64 `json:"wall_time"`
+}
+
+// Logger is an [nn.Observer] that writes an entry on every evaluation. The
+// datasets are expected to be named "training", "tests" and "validation".
+type Logger struct {
+	nn.Hooks
+
+	// Context is the name the entries are written with.
+	Context string
+
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+
+	// size is the largest batch since the previous entry, and norm and
+	// batches, the sum of the norms of their gradients and their count.
+	size    int
+	norm    float64
+	batches int
+
+	err error
+}
+
+// NewLogger returns a logger that writes onto w.
+func NewLogger(w io.Writer, context string) *Logger {
+	return &Logger{Context: context, w: w, start: time.Now()}
+}
+
+// Create returns a logger that appends onto the file at path, creating it if
+// needed. The logger must be closed.
+func Create(path string, context string) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLogger(f, context), nil
+}
+
+func (l *Logger) OnBatchEnd(_ *nn.Trainer, e nn.BatchEnd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.size = max(l.size, e.Size)
+	l.norm += e.GradientNorm
+	l.batches++
+}
+
+func (l *Logger) OnEvaluate(_ *nn.Trainer, e nn.Evaluated) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry := Entry{
+		Time:         now,
+		Context:      l.Context,
+		Cycle:        e.Cycle,
+		BatchSize:    l.size,
+		LearningRate: e.Rate,
+		WallTime:     now.Sub(l.start).Seconds(),
+	}
+
+	if eval, in := e.Evaluations["training"]; in {
+		entry.TrainingCost, entry.TrainingAccuracy = finite(eval.Cost), accuracy(eval)
+	}
+	if eval, in := e.Evaluations["tests"]; in {
+		entry.TestCost, entry.TestAccuracy = finite(eval.Cost), accuracy(eval)
+	}
+	if eval, in := e.Evaluations["validation"]; in {
+		entry.ValidationCost, entry.ValidationAccuracy = finite(eval.Cost), accuracy(eval)
+	}
+	if l.batches > 0 {
+		entry.GradientNorm = finite(l.norm / float64(l.batches))
+	}
+
+	l.size, l.norm, l.batches = 0, 0, 0
+
+	line, err := json.Marshal(entry)
+	if err == nil {
+		_, err = l.w.Write(append(line, '\n'))
+	}
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+}
+
+// Err returns the first error writing the entries, if any.
+func (l *Logger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Close closes the writer of the logger, if it is an [io.Closer], and
+// returns the first error writing the entries, if any.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.w.(io.Closer); ok {
+		if err := c.Close(); l.err == nil {
+			l.err = err
+		}
+	}
+
+	return l.err
+}
+
+// Read reads every entry of a metrics log, blank lines are skipped.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ReadFile reads every entry of the metrics log at path, see [Read].
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// accuracy returns the accuracy of the evaluation, or nil for regression
+// tasks, which have no notion of it.
+func accuracy(eval *nn.Evaluation) *float64 {
+	if eval.Task == nn.TaskRegression {
+		return nil
+	}
+
+	return finite(eval.Accuracy())
+}
+
+// finite returns a pointer to v, or nil if it is not finite.
+func finite(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,382 @@
+package nn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alan-b-lima/nn-digits/pkg/mem"
+)
+
+// DType is the type of the weights and biases stored in a binary model.
+type DType uint8
+
+const (
+	// DTypeFloat64 stores values as little endian IEEE 754 doubles, the
+	// same precision used in memory.
+	DTypeFloat64 DType = 1
+
+	// DTypeFloat32 stores values as little endian IEEE 754 singles, half the
+	// size, at a loss of precision.
+	DTypeFloat32 DType = 2
+)
+
+// Size returns the number of bytes a single value takes.
+func (d DType) Size() int {
+	switch d {
+	case DTypeFloat64:
+		return 8
+	case DTypeFloat32:
+		return 4
+	}
+
+	return 0
+}
+
+func (d DType) String() string {
+	switch d {
+	case DTypeFloat64:
+		return "float64"
+	case DTypeFloat32:
+		return "float32"
+	}
+
+	return fmt.Sprintf("DType(%d)", uint8(d))
+}
+
+// ParseDType returns the dtype with the given name, "float64" or "float32".
+func ParseDType(name string) (DType, error) {
+	switch name {
+	case "float64":
+		return DTypeFloat64, nil
+	case "float32":
+		return DTypeFloat32, nil
+	}
+
+	return 0, fmt.Errorf("unknown dtype %q", name)
+}
+
+// model_magic identifies a binary model file, model_version is bumped on any
+// incompatible change to the layout.
+const (
+	model_magic   = "NNDM"
+	model_version = 1
+)
+
+// activation_relu identifies the activation of the hidden layers, the only
+// one there is so far.
+const activation_relu = 1
+
+// model_header is the fixed-size header of a binary model, all fields are
+// little endian. It is followed by Layers uint32 dimensions, MetadataSize
+// bytes of JSON metadata and PayloadSize bytes of weights and biases of type
+// DType, in the same order as in memory. Checksum is the CRC-32 (IEEE) of
+// everything that follows the header.
+type model_header struct {
+	Magic        [4]byte
+	Version      uint16
+	DType        DType
+	Task         Task
+	Activation   uint8
+	_            [3]uint8
+	Layers       uint32
+	MetadataSize uint32
+	PayloadSize  uint64
+	Checksum     uint32
+}
+
+// Limits of the headers that are accepted, so that corrupt or crafted ones
+// fail instead of exhausting the memory: max_model_layers is the largest
+// number of layers, and max_model_payload, the largest size of the weights and
+// biases in bytes.
+const (
+	max_model_layers  = 1 << 16
+	max_model_payload = 1 << 34
+)
+
+// model_metadata is the metadata section of a binary model.
+type model_metadata struct {
+	Classes  []string `json:"classes,omitempty"`
+	Metadata Metadata `json:"metadata,omitzero"`
+}
+
+var (
+	ErrModelMagic      = errors.New("not a binary model")
+	ErrModelVersion    = errors.New("unsupported binary model version")
+	ErrModelDType      = errors.New("unsupported binary model dtype")
+	ErrModelActivation = errors.New("unsupported binary model activation")
+	ErrModelChecksum   = errors.New("binary model checksum mismatch, the file is corrupted")
+	ErrModelCorrupt    = errors.New("binary model is corrupted")
+)
+
+// params_of returns the number of weights and biases of a network with the
+// given dimensions, as size_nn does, and whether it is free of overflows.
+func params_of(dims []int) (uint64, bool) {
+	var count uint64
+	for i := range len(dims) - 1 {
+		hi, weights := bits.Mul64(uint64(dims[i+1]), uint64(dims[i]))
+		if hi != 0 {
+			return 0, false
+		}
+
+		var c1, c2 uint64
+		count, c1 = bits.Add64(count, weights, 0)
+		count, c2 = bits.Add64(count, uint64(dims[i+1]), 0)
+		if c1 != 0 || c2 != 0 {
+			return 0, false
+		}
+	}
+
+	return count, count <= math.MaxInt
+}
+
+// IsBinary reports whether header, the first bytes of a file, is that of a
+// binary model.
+func IsBinary(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(model_magic))
+}
+
+// Load loads a network either in the binary model format or in JSON, as
+// marshaled by [NeuralNetwork.MarshalJSON].
+func Load(r io.Reader) (*NeuralNetwork, error) {
+	br := bufio.NewReader(r)
+	if header, _ := br.Peek(len(model_magic)); IsBinary(header) {
+		return LoadFromBinary(br)
+	}
+
+	var nn NeuralNetwork
+	if err := json.NewDecoder(br).Decode(&nn); err != nil {
+		return nil, err
+	}
+
+	return &nn, nil
+}
+
+// LoadFile loads the network stored in the file at path, in either format,
+// see [Load].
+func LoadFile(path string) (*NeuralNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// StoreFile stores the network onto the file at path, as JSON if its
+// extension is .json, or in the binary model format with the given dtype
+// otherwise.
+func StoreFile(path string, nn *NeuralNetwork, dtype DType) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return json.NewEncoder(f).Encode(nn)
+	}
+
+	return StoreToBinary(f, nn, dtype)
+}
+
+// MarshalBinary encodes the network in the binary model format, with weights
+// and biases as float64.
+func (nn *NeuralNetwork) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := StoreToBinary(&buf, nn, DTypeFloat64); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a network in the binary model format onto nn.
+func (nn *NeuralNetwork) UnmarshalBinary(data []byte) error {
+	loaded, err := LoadFromBinary(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	nn.buf = loaded.buf
+	nn.names = loaded.names
+	nn.task = loaded.task
+	nn.meta = loaded.meta
+
+	nn.layers = loaded.layers
+	nn.comp = mem.NewPool(nn.new_comp)
+	nn.learn = mem.NewPool(nn.new_learn)
+
+	return nil
+}
+
+// StoreToBinary stores the network in the binary model format, with weights
+// and biases of the given dtype.
+func StoreToBinary(w io.Writer, nn *NeuralNetwork, dtype DType) error {
+	if dtype.Size() == 0 {
+		return ErrModelDType
+	}
+
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	if len(nn.layers) == 0 {
+		return errors.New("cannot store an empty network")
+	}
+
+	var body []byte
+
+	dims := nn.Dims()
+	for _, dim := range dims {
+		body = binary.LittleEndian.AppendUint32(body, uint32(dim))
+	}
+
+	metadata, err := json.Marshal(model_metadata{Classes: nn.names, Metadata: nn.meta})
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+	body = append(body, metadata...)
+
+	payload := len(body)
+	for _, v := range nn.buf {
+		switch dtype {
+		case DTypeFloat64:
+			body = binary.LittleEndian.AppendUint64(body, math.Float64bits(v))
+		case DTypeFloat32:
+			body = binary.LittleEndian.AppendUint32(body, math.Float32bits(float32(v)))
+		}
+	}
+
+	h := model_header{
+		Version:      model_version,
+		DType:        dtype,
+		Task:         nn.task,
+		Activation:   activation_relu,
+		Layers:       uint32(len(dims)),
+		MetadataSize: uint32(len(metadata)),
+		PayloadSize:  uint64(len(body) - payload),
+		Checksum:     crc32.ChecksumIEEE(body),
+	}
+	copy(h.Magic[:], model_magic)
+
+	bw := bufio.NewWriter(w)
+	if err := binary.Write(bw, binary.LittleEndian, &h); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	if _, err := bw.Write(body); err != nil {
+		return fmt.Errorf("write model: %w", err)
+	}
+
+	return bw.Flush()
+}
+
+// LoadFromBinary loads a network stored with [StoreToBinary]. It fails with
+// [ErrModelVersion] on models of newer versions and with [ErrModelChecksum]
+// on corrupted ones.
+func LoadFromBinary(r io.Reader) (*NeuralNetwork, error) {
+	var h model_header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	if string(h.Magic[:]) != model_magic {
+		return nil, ErrModelMagic
+	}
+	if h.Version != model_version {
+		return nil, fmt.Errorf("%w: %d, expected %d", ErrModelVersion, h.Version, model_version)
+	}
+	if h.DType.Size() == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrModelDType, h.DType)
+	}
+	if h.Activation != activation_relu {
+		return nil, fmt.Errorf("%w: %d", ErrModelActivation, h.Activation)
+	}
+	if _, err := h.Task.MarshalText(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrModelCorrupt, err)
+	}
+	if h.Layers < 2 || h.PayloadSize%uint64(h.DType.Size()) != 0 {
+		return nil, ErrModelCorrupt
+	}
+	if h.Layers > max_model_layers || h.PayloadSize > max_model_payload {
+		return nil, fmt.Errorf("%w: %d layers, %d bytes of payload", ErrModelCorrupt, h.Layers, h.PayloadSize)
+	}
+
+	// the sum neither overflows nor exceeds an int64, given the limits
+	size := 4*uint64(h.Layers) + uint64(h.MetadataSize) + h.PayloadSize
+	body := make([]byte, 0, min(size, 1<<20))
+
+	buf := bytes.NewBuffer(body)
+	if n, err := io.CopyN(buf, r, int64(size)); err != nil {
+		return nil, fmt.Errorf("%w: read %d of %d bytes: %w", ErrModelCorrupt, n, size, err)
+	}
+	body = buf.Bytes()
+	if uint64(len(body)) != size {
+		return nil, fmt.Errorf("%w: read %d of %d bytes", ErrModelCorrupt, len(body), size)
+	}
+
+	if crc32.ChecksumIEEE(body) != h.Checksum {
+		return nil, ErrModelChecksum
+	}
+
+	dims := make([]int, h.Layers)
+	for i := range dims {
+		dims[i] = int(binary.LittleEndian.Uint32(mem.Take(&body, 4)))
+		if dims[i] == 0 {
+			return nil, ErrModelCorrupt
+		}
+	}
+
+	var metadata model_metadata
+	if err := json.Unmarshal(mem.Take(&body, int(h.MetadataSize)), &metadata); err != nil {
+		return nil, fmt.Errorf("%w: metadata: %w", ErrModelCorrupt, err)
+	}
+	if metadata.Classes != nil && len(metadata.Classes) != dims[len(dims)-1] {
+		return nil, fmt.Errorf("%w: number of classes does not match the number of output neurons", ErrModelCorrupt)
+	}
+
+	count, ok := params_of(dims)
+	if hi, lo := bits.Mul64(count, uint64(h.DType.Size())); !ok || hi != 0 || lo != h.PayloadSize {
+		return nil, fmt.Errorf("%w: payload does not match the dimensions", ErrModelCorrupt)
+	}
+
+	values := make([]float64, int(count))
+	for i := range values {
+		switch h.DType {
+		case DTypeFloat64:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(mem.Take(&body, 8)))
+		case DTypeFloat32:
+			values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(mem.Take(&body, 4))))
+		}
+	}
+
+	nn := NeuralNetwork{
+		buf:   values,
+		names: metadata.Classes,
+		task:  h.Task,
+		meta:  metadata.Metadata,
+	}
+
+	nn.layers = slice_nn(nn.buf, dims...)
+	nn.comp = mem.NewPool(nn.new_comp)
+	nn.learn = mem.NewPool(nn.new_learn)
+
+	return &nn, nil
+}
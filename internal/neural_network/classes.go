@@ -0,0 +1,63 @@
+package nn
+
+import "strconv"
+
+// Classes describes a label space: the number of classes and, optionally,
+// their names.
+type Classes struct {
+	Count int
+	Names []string
+}
+
+// Name returns the name of the i-th class, or its number if it has no name.
+func (c Classes) Name(i int) string {
+	if 0 <= i && i < len(c.Names) && c.Names[i] != "" {
+		return c.Names[i]
+	}
+
+	return strconv.Itoa(i)
+}
+
+// Labeled is a dataset that carries its label space.
+type Labeled interface {
+	Dataset
+	Classes() Classes
+}
+
+// WithClasses returns a view of the dataset that carries the given label
+// space.
+func WithClasses(d Dataset, c Classes) Dataset {
+	return labeled{unlabeled(d), c}
+}
+
+// ClassesOf returns the label space of a dataset, as carried by it if it is
+// [Labeled], or else with as many classes as the size of the labels.
+func ClassesOf(d Dataset) Classes {
+	if l, ok := d.(Labeled); ok {
+		return l.Classes()
+	}
+
+	if d.Len() == 0 {
+		return Classes{}
+	}
+
+	return Classes{Count: d.Get(0).Label.Size()}
+}
+
+type labeled struct {
+	Dataset
+	classes Classes
+}
+
+func (l labeled) Classes() Classes {
+	return l.classes
+}
+
+// unlabeled strips the label space added by [WithClasses], if any.
+func unlabeled(d Dataset) Dataset {
+	if l, ok := d.(labeled); ok {
+		return l.Dataset
+	}
+
+	return d
+}
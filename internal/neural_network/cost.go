@@ -6,11 +6,18 @@ import (
 	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
 )
 
-func (nn *NeuralNetwork) Performance(dataset []Sample) (correct int, cost float64) {
+// Performance returns the number of correctly predicted samples of the dataset
+// and the mean squared error over it. A sample is correct if its most
+// probable class is the label, in classification tasks, or if every class is
+// correctly predicted, in multi-label tasks. Regression tasks have no notion
+// of correctness, so correct is always zero.
+func (nn *NeuralNetwork) Performance(dataset Dataset) (correct int, cost float64) {
 	comp := nn.get_comp()
 	defer nn.free_comp(comp)
 
-	for _, sample := range dataset {
+	task := nn.Task()
+
+	for _, sample := range All(dataset) {
 		nn.feed_forward(comp, sample.Values)
 
 		output := (*comp)[len(*comp)-1].Activation.Data()
@@ -21,15 +28,12 @@ func (nn *NeuralNetwork) Performance(dataset []Sample) (correct int, cost float6
 			cost += diff * diff
 		}
 
-		class := index_of_max(output)
-		label := index_of_max(expected)
-
-		if class == label {
+		if is_correct(task, output, expected) {
 			correct++
 		}
 	}
 
-	return correct, .5 * cost / float64(len(dataset))
+	return correct, .5 * cost / float64(dataset.Len())
 }
 
 func (nn *NeuralNetwork) sample_cost_derivative(comp *[]computation, cost nnmath.Vector, sample Sample) {
@@ -41,7 +45,27 @@ func (nn *NeuralNetwork) sample_cost_derivative(comp *[]computation, cost nnmath
 	nnmath.Sub(cost, output, expected)
 }
 
-func index_of_max[T ~[]E, E cmp.Ordered](s T) int {
+func is_correct(task Task, output, expected []float64) bool {
+	switch task {
+	case TaskClassification:
+		return IndexOfMax(output) == IndexOfMax(expected)
+
+	case TaskMultiLabel:
+		for i := range output {
+			if predicted(output[i]) != predicted(expected[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// IndexOfMax returns the index of the largest element of s, the first of them
+// on ties, or -1 if s is empty. It is the class predicted from the output of a
+// classification network, or labelled by a one-hot encoded label.
+func IndexOfMax[T ~[]E, E cmp.Ordered](s T) int {
 	if len(s) == 0 {
 		return -1
 	}
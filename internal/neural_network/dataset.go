@@ -0,0 +1,167 @@
+package nn
+
+import "iter"
+
+// Dataset is an indexed collection of samples.
+//
+// Implementations must be safe for concurrent calls of Get.
+type Dataset interface {
+	// Len returns the number of samples in the dataset.
+	Len() int
+
+	// Get returns the sample at index i.
+	//
+	// Get panics if i is out of range [0, Len()).
+	Get(i int) Sample
+}
+
+// Samples is an in-memory dataset.
+type Samples []Sample
+
+func (s Samples) Len() int {
+	return len(s)
+}
+
+func (s Samples) Get(i int) Sample {
+	return s[i]
+}
+
+// All returns an iterator over the indexes and samples of a dataset.
+func All(d Dataset) iter.Seq2[int, Sample] {
+	return func(yield func(int, Sample) bool) {
+		for i := range d.Len() {
+			if !yield(i, d.Get(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Batches returns an iterator over contiguous batches of the dataset with the
+// given size, the last one may be smaller.
+func Batches(d Dataset, size int) iter.Seq[Dataset] {
+	return func(yield func(Dataset) bool) {
+		size := max(size, 1)
+		for from := 0; from < d.Len(); from += size {
+			if !yield(Slice(d, from, min(from+size, d.Len()))) {
+				return
+			}
+		}
+	}
+}
+
+// Slice returns a view of the samples of a dataset in the range [from, to).
+// The label space of [Labeled] datasets is kept.
+//
+// Slice panics if the range is out of bounds.
+func Slice(d Dataset, from, to int) Dataset {
+	if from < 0 || to < from || d.Len() < to {
+		panic("slice bounds out of range")
+	}
+
+	if l, ok := d.(Labeled); ok {
+		return WithClasses(slice(unlabeled(d), from, to), l.Classes())
+	}
+
+	return slice(d, from, to)
+}
+
+func slice(d Dataset, from, to int) Dataset {
+	switch d := d.(type) {
+	case Samples:
+		return d[from:to]
+	case window:
+		return window{d.Dataset, d.from + from, d.from + to}
+	}
+
+	return window{d, from, to}
+}
+
+type window struct {
+	Dataset
+	from, to int
+}
+
+func (w window) Len() int {
+	return w.to - w.from
+}
+
+func (w window) Get(i int) Sample {
+	if i < 0 || w.Len() <= i {
+		panic("index out of range")
+	}
+
+	return w.Dataset.Get(w.from + i)
+}
+
+// Concat returns a dataset with the samples of all the given datasets, in
+// order. In-memory datasets are concatenated into a new in-memory dataset. The
+// label space of the first [Labeled] dataset, if any, is kept.
+func Concat(ds ...Dataset) Dataset {
+	var parts []Dataset
+	var classes *Classes
+	in_memory := true
+
+	for _, d := range ds {
+		if d == nil || d.Len() == 0 {
+			continue
+		}
+
+		if l, ok := d.(Labeled); ok && classes == nil {
+			c := l.Classes()
+			classes = &c
+		}
+		d = unlabeled(d)
+
+		parts = append(parts, d)
+		if _, ok := d.(Samples); !ok {
+			in_memory = false
+		}
+	}
+
+	if classes != nil {
+		return WithClasses(concat_parts(parts, in_memory), *classes)
+	}
+
+	return concat_parts(parts, in_memory)
+}
+
+func concat_parts(parts []Dataset, in_memory bool) Dataset {
+	switch {
+	case len(parts) == 0:
+		return Samples{}
+	case len(parts) == 1:
+		return parts[0]
+	case !in_memory:
+		return concat(parts)
+	}
+
+	var ss Samples
+	for _, d := range parts {
+		ss = append(ss, d.(Samples)...)
+	}
+
+	return ss
+}
+
+type concat []Dataset
+
+func (c concat) Len() int {
+	var n int
+	for _, d := range c {
+		n += d.Len()
+	}
+
+	return n
+}
+
+func (c concat) Get(i int) Sample {
+	for _, d := range c {
+		if i < d.Len() {
+			return d.Get(i)
+		}
+		i -= d.Len()
+	}
+
+	panic("index out of range")
+}
@@ -0,0 +1,315 @@
+package nn
+
+import "math"
+
+// Evaluation holds the metrics of a network against a dataset, as computed by
+// [NeuralNetwork.Evaluate]. Which metrics are meaningful depends on the task.
+type Evaluation struct {
+	// Task is the task of the evaluated network.
+	Task Task
+
+	// Confusion is the confusion matrix, Confusion[i][j] is the number of
+	// samples labeled as class i that were classified as class j. It is only
+	// computed for classification tasks.
+	Confusion [][]int
+
+	// Outcomes are the outcomes of each class, taken as a binary problem of
+	// its own. It is only computed for multi-label tasks.
+	Outcomes []Outcomes
+
+	// Total is the number of samples evaluated.
+	Total int
+
+	// Correct is the number of correctly predicted samples, as defined by
+	// [NeuralNetwork.Performance].
+	Correct int
+
+	// K is the k used for the top-k accuracy, and TopK is the number of
+	// samples whose label is among the K most probable classes. TopK is only
+	// computed for classification tasks.
+	K    int
+	TopK int
+
+	// Cost is the mean squared error, halved, the same as the one reported
+	// by [NeuralNetwork.Performance].
+	Cost float64
+
+	// LogLoss is the mean cross-entropy between the output and the label, for
+	// multi-label tasks, it is the binary cross-entropy summed over classes.
+	LogLoss float64
+
+	// MAE is the mean absolute error, summed over outputs.
+	MAE float64
+
+	// R2 is the coefficient of determination, pooled over outputs.
+	R2 float64
+}
+
+// Outcomes counts the true and false, positive and negative, predictions of a
+// single class.
+type Outcomes struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	TrueNegatives  int
+}
+
+// epsilon clamps probabilities away from zero when computing the log-loss.
+const epsilon = 1e-15
+
+// Evaluate runs every sample of the dataset through the network and computes
+// the metrics for its task, k is used for the top-k accuracy.
+func (nn *NeuralNetwork) Evaluate(dataset Dataset, k int) *Evaluation {
+	classes := nn.Responses()
+	task := nn.Task()
+
+	eval := Evaluation{
+		Task:  task,
+		Total: dataset.Len(),
+		K:     k,
+	}
+
+	switch task {
+	case TaskClassification:
+		eval.Confusion = make([][]int, classes)
+
+		buf := make([]int, classes*classes)
+		for i := range classes {
+			eval.Confusion[i] = buf[i*classes : (i+1)*classes]
+		}
+
+	case TaskMultiLabel:
+		eval.Outcomes = make([]Outcomes, classes)
+	}
+
+	// sums and sums of squares of the labels, for the R²
+	sum := make([]float64, classes)
+	sum_sq := make([]float64, classes)
+	var residual float64
+
+	comp := nn.get_comp()
+	defer nn.free_comp(comp)
+
+	for _, sample := range All(dataset) {
+		nn.feed_forward(comp, sample.Values)
+
+		output := (*comp)[len(*comp)-1].Activation.Data()
+		expected := sample.Label.Data()
+
+		for i := range len(output) {
+			diff := output[i] - expected[i]
+			residual += diff * diff
+			eval.MAE += math.Abs(diff)
+
+			sum[i] += expected[i]
+			sum_sq[i] += expected[i] * expected[i]
+		}
+
+		if is_correct(task, output, expected) {
+			eval.Correct++
+		}
+
+		switch task {
+		case TaskClassification:
+			eval.classification(output, expected)
+		case TaskMultiLabel:
+			eval.multi_label(output, expected)
+		}
+	}
+
+	if n := float64(dataset.Len()); n > 0 {
+		var total float64
+		for i := range classes {
+			total += sum_sq[i] - sum[i]*sum[i]/n
+		}
+		if total > 0 {
+			eval.R2 = 1 - residual/total
+		}
+
+		eval.Cost = .5 * residual / n
+		eval.LogLoss /= n
+		eval.MAE /= n
+	}
+
+	return &eval
+}
+
+func (e *Evaluation) classification(output, expected []float64) {
+	for i := range output {
+		if expected[i] > 0 {
+			e.LogLoss -= expected[i] * math.Log(max(output[i], epsilon))
+		}
+	}
+
+	class := IndexOfMax(output)
+	label := IndexOfMax(expected)
+
+	e.Confusion[label][class]++
+
+	var above int
+	for _, v := range output {
+		if v > output[label] {
+			above++
+		}
+	}
+	if above < e.K {
+		e.TopK++
+	}
+}
+
+func (e *Evaluation) multi_label(output, expected []float64) {
+	for i := range output {
+		p := min(max(output[i], epsilon), 1-epsilon)
+		e.LogLoss -= expected[i]*math.Log(p) + (1-expected[i])*math.Log(1-p)
+
+		outcomes := &e.Outcomes[i]
+		switch pred, label := predicted(output[i]), predicted(expected[i]); {
+		case pred && label:
+			outcomes.TruePositives++
+		case pred && !label:
+			outcomes.FalsePositives++
+		case !pred && label:
+			outcomes.FalseNegatives++
+		default:
+			outcomes.TrueNegatives++
+		}
+	}
+}
+
+// Classes returns the number of classes in the evaluation, zero for regression
+// tasks.
+func (e *Evaluation) Classes() int {
+	if e.Task == TaskMultiLabel {
+		return len(e.Outcomes)
+	}
+
+	return len(e.Confusion)
+}
+
+// Outcome returns the outcomes of the given class, taken as a binary problem
+// of its own.
+func (e *Evaluation) Outcome(class int) Outcomes {
+	if e.Task == TaskMultiLabel {
+		return e.Outcomes[class]
+	}
+
+	var support, predicted int
+	for i := range e.Confusion {
+		support += e.Confusion[class][i]
+		predicted += e.Confusion[i][class]
+	}
+
+	o := Outcomes{TruePositives: e.Confusion[class][class]}
+	o.FalsePositives = predicted - o.TruePositives
+	o.FalseNegatives = support - o.TruePositives
+	o.TrueNegatives = e.Total - o.TruePositives - o.FalsePositives - o.FalseNegatives
+
+	return o
+}
+
+// Accuracy returns the ratio of correctly predicted samples.
+func (e *Evaluation) Accuracy() float64 {
+	return ratio(e.Correct, e.Total)
+}
+
+// TopKAccuracy returns the ratio of samples whose label is among the K most
+// probable classes.
+func (e *Evaluation) TopKAccuracy() float64 {
+	return ratio(e.TopK, e.Total)
+}
+
+// HammingLoss returns the ratio of wrongly predicted labels, over every class
+// of every sample.
+func (e *Evaluation) HammingLoss() float64 {
+	var wrong int
+	for class := range e.Classes() {
+		o := e.Outcome(class)
+		wrong += o.FalsePositives + o.FalseNegatives
+	}
+
+	return ratio(wrong, e.Total*e.Classes())
+}
+
+// MSE returns the mean squared error, summed over outputs.
+func (e *Evaluation) MSE() float64 {
+	return 2 * e.Cost
+}
+
+// Support returns the number of samples labeled as the given class.
+func (e *Evaluation) Support(class int) int {
+	o := e.Outcome(class)
+	return o.TruePositives + o.FalseNegatives
+}
+
+// Predicted returns the number of samples classified as the given class.
+func (e *Evaluation) Predicted(class int) int {
+	o := e.Outcome(class)
+	return o.TruePositives + o.FalsePositives
+}
+
+// Precision returns the ratio of samples classified as the given class that
+// are actually labeled as such.
+func (e *Evaluation) Precision(class int) float64 {
+	return ratio(e.Outcome(class).TruePositives, e.Predicted(class))
+}
+
+// Recall returns the ratio of samples labeled as the given class that are
+// classified as such.
+func (e *Evaluation) Recall(class int) float64 {
+	return ratio(e.Outcome(class).TruePositives, e.Support(class))
+}
+
+// F1 returns the harmonic mean of the precision and recall of the given class.
+func (e *Evaluation) F1(class int) float64 {
+	return f1(e.Precision(class), e.Recall(class))
+}
+
+// Macro returns the unweighted mean of the precision, recall and F1 of every
+// class.
+func (e *Evaluation) Macro() (precision, recall, f float64) {
+	classes := e.Classes()
+	if classes == 0 {
+		return 0, 0, 0
+	}
+
+	for class := range classes {
+		precision += e.Precision(class)
+		recall += e.Recall(class)
+		f += e.F1(class)
+	}
+
+	n := float64(classes)
+	return precision / n, recall / n, f / n
+}
+
+// Micro returns the precision, recall and F1 computed from the true positives,
+// false positives and false negatives summed over every class.
+func (e *Evaluation) Micro() (precision, recall, f float64) {
+	var tp, predicted, support int
+	for class := range e.Classes() {
+		tp += e.Outcome(class).TruePositives
+		predicted += e.Predicted(class)
+		support += e.Support(class)
+	}
+
+	precision = ratio(tp, predicted)
+	recall = ratio(tp, support)
+
+	return precision, recall, f1(precision, recall)
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(d)
+}
+
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+
+	return 2 * precision * recall / (precision + recall)
+}
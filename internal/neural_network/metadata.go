@@ -0,0 +1,120 @@
+package nn
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"time"
+)
+
+// Metadata describes how a network was made. It is stored along with the
+// network, but plays no part in its computations.
+type Metadata struct {
+	// Created is when the network was created.
+	Created time.Time `json:"created,omitzero"`
+
+	// Cycles is the number of training cycles the network went through.
+	Cycles int `json:"cycles,omitempty"`
+
+	// Hyperparameters are the hyperparameters in effect when the network
+	// was last stored.
+	Hyperparameters Hyperparameters `json:"hyperparameters,omitzero"`
+
+	// History is the performance of the network along its training.
+	History []Record `json:"history,omitempty"`
+
+	// Datasets are the datasets the network was trained and tested with.
+	Datasets []DatasetInfo `json:"datasets,omitempty"`
+
+	// Notes are free-form notes.
+	Notes string `json:"notes,omitempty"`
+}
+
+// Hyperparameters are the training hyperparameters of a network.
+type Hyperparameters struct {
+	LearningRate float64  `json:"learning_rate,omitempty"`
+	BatchSize    int      `json:"batch_size,omitempty"`
+	Augmentation []string `json:"augmentation,omitempty"`
+}
+
+// Record is the performance of a network against its test dataset at a given
+// training cycle.
+type Record struct {
+	Cycle    int     `json:"cycle"`
+	Cost     float64 `json:"cost"`
+	Accuracy float64 `json:"accuracy"`
+
+	// TrainingCost and ValidationCost are the costs against the training and
+	// validation datasets, and LearningRate, the learning rate in effect, all
+	// zero if not measured.
+	TrainingCost   float64 `json:"training_cost,omitempty"`
+	ValidationCost float64 `json:"validation_cost,omitempty"`
+	LearningRate   float64 `json:"learning_rate,omitempty"`
+}
+
+// DatasetInfo identifies a dataset file used with a network.
+type DatasetInfo struct {
+	// Set is the role of the dataset, e.g., training or tests.
+	Set string `json:"set"`
+
+	// Path is the path of the file, and Extra, any other files it was loaded
+	// with, e.g., the labels of IDX images.
+	Path  string   `json:"path"`
+	Extra []string `json:"extra,omitempty"`
+
+	// Count and Hash are the fingerprint of the dataset, see [Fingerprint].
+	Count int    `json:"count"`
+	Hash  string `json:"hash"`
+}
+
+// Metadata returns a copy of the metadata of the network.
+func (nn *NeuralNetwork) Metadata() Metadata {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	return nn.meta.clone()
+}
+
+// SetMetadata replaces the metadata of the network by a copy of meta.
+func (nn *NeuralNetwork) SetMetadata(meta Metadata) {
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	nn.meta = meta.clone()
+}
+
+func (m Metadata) clone() Metadata {
+	m.Hyperparameters.Augmentation = append([]string(nil), m.Hyperparameters.Augmentation...)
+	m.History = append([]Record(nil), m.History...)
+	m.Datasets = append([]DatasetInfo(nil), m.Datasets...)
+
+	for i := range m.Datasets {
+		m.Datasets[i].Extra = append([]string(nil), m.Datasets[i].Extra...)
+	}
+
+	return m
+}
+
+// Fingerprint returns the number of samples in the dataset and the SHA-256 of
+// their labels and values, hex encoded. The hash is over the decoded float64
+// values, so a dataset keeps it when converted without loss, but not when a
+// lossy conversion, e.g., from float32 to uint8, changes its values.
+func Fingerprint(dataset Dataset) (count int, hash string) {
+	h := sha256.New()
+
+	var buf []byte
+	for _, sample := range All(dataset) {
+		buf = buf[:0]
+		for _, values := range [][]float64{sample.Label.Data(), sample.Values.Data()} {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(values)))
+			for _, v := range values {
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+			}
+		}
+
+		h.Write(buf)
+	}
+
+	return dataset.Len(), hex.EncodeToString(h.Sum(nil))
+}
@@ -1,8 +1,11 @@
 package nn
 
 import (
+	"math"
 	"math/rand/v2"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/alan-b-lima/nn-digits/pkg/mem"
 	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
@@ -26,6 +29,15 @@ type NeuralNetwork struct {
 	// also useful for cache locality.
 	buf []float64
 
+	// names are the optional names of the output classes.
+	names []string
+
+	// task determines the activation of the output layer.
+	task Task
+
+	// meta describes how the network was made.
+	meta Metadata
+
 	comp  mem.Pool[*[]computation] // no need to lock for comp
 	learn mem.Pool[*[]learning]    // no need to lock for learn
 
@@ -38,25 +50,132 @@ type layer struct {
 }
 
 func New(dims ...int) *NeuralNetwork {
+	return new_nn(rand.NormFloat64, dims...)
+}
+
+// NewRand is like [New], but draws the initial weights and biases from rng,
+// so that networks created with equally seeded generators are the same.
+func NewRand(rng *rand.Rand, dims ...int) *NeuralNetwork {
+	return new_nn(rng.NormFloat64, dims...)
+}
+
+func new_nn(norm func() float64, dims ...int) *NeuralNetwork {
 	if len(dims) < 2 {
 		panic("there must be at least two layers")
 	}
 
 	nn := NeuralNetwork{
-		buf: make([]float64, size_nn(dims...)),
+		buf:  make([]float64, size_nn(dims...)),
+		meta: Metadata{Created: time.Now().Truncate(time.Second)},
 	}
 
 	nn.comp = mem.NewPool(nn.new_comp)
 	nn.learn = mem.NewPool(nn.new_learn)
 
 	for i := range len(nn.buf) {
-		nn.buf[i] = rand.NormFloat64()
+		nn.buf[i] = norm()
 	}
 
 	nn.layers = slice_nn(nn.buf, dims...)
 	return &nn
 }
 
+// Clone returns a deep copy of the network, i.e., of its weights, biases,
+// class names, task and metadata.
+func (nn *NeuralNetwork) Clone() *NeuralNetwork {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	clone := NeuralNetwork{
+		buf:   slices.Clone(nn.buf),
+		names: slices.Clone(nn.names),
+		task:  nn.task,
+		meta:  nn.meta.clone(),
+	}
+
+	clone.comp = mem.NewPool(clone.new_comp)
+	clone.learn = mem.NewPool(clone.new_learn)
+
+	if len(nn.layers) > 0 {
+		clone.layers = slice_nn(clone.buf, nn.Dims()...)
+	}
+
+	return &clone
+}
+
+// Parameters returns a copy of the weights and biases of the network, layer
+// after layer, in a single slice.
+func (nn *NeuralNetwork) Parameters() []float64 {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	return slices.Clone(nn.buf)
+}
+
+// SetParameters replaces the weights and biases of the network by a copy of
+// params, laid out as by [NeuralNetwork.Parameters].
+//
+// SetParameters panics if the length of params is different from that of
+// [NeuralNetwork.Parameters]().
+func (nn *NeuralNetwork) SetParameters(params []float64) {
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	if len(params) != len(nn.buf) {
+		panic("number of parameters does not match the dimensions of the network")
+	}
+
+	copy(nn.buf, params)
+}
+
+// Distance returns, for each layer but the input one, the Euclidean distance
+// between the weights, and between the biases, of the networks.
+//
+// Distance panics if the dimensions of the networks differ.
+func (nn *NeuralNetwork) Distance(other *NeuralNetwork) (weights, biases []float64) {
+	if !slices.Equal(nn.Dims(), other.Dims()) {
+		panic("the dimensions of the networks differ")
+	}
+
+	weights = make([]float64, len(nn.layers))
+	biases = make([]float64, len(nn.layers))
+	if nn == other {
+		return weights, biases
+	}
+
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	for i := range nn.layers {
+		weights[i] = distance(nn.layers[i].Weights.Data(), other.layers[i].Weights.Data())
+		biases[i] = distance(nn.layers[i].Biases.Data(), other.layers[i].Biases.Data())
+	}
+
+	return weights, biases
+}
+
+// Layer returns a copy of the weights, row by row, and of the biases of the
+// i-th layer, counting from the first layer after the input one.
+func (nn *NeuralNetwork) Layer(i int) (weights, biases []float64) {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	return slices.Clone(nn.layers[i].Weights.Data()), slices.Clone(nn.layers[i].Biases.Data())
+}
+
+func distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+
+	return math.Sqrt(sum)
+}
+
 // Len returns the number of layers in the network, counting the input layer as
 // a layer.
 func (nn *NeuralNetwork) Len() int {
@@ -86,6 +205,48 @@ func (nn *NeuralNetwork) Dims() []int {
 	return dims
 }
 
+// Classes returns the label space of the network, i.e., as many classes as
+// output neurons, with their names, if set.
+func (nn *NeuralNetwork) Classes() Classes {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	return Classes{Count: nn.Responses(), Names: nn.names}
+}
+
+// SetClassNames sets the names of the output classes, a nil slice removes
+// them.
+//
+// SetClassNames panics if names is not nil and its length is different from
+// [NeuralNetwork.Responses]().
+func (nn *NeuralNetwork) SetClassNames(names []string) {
+	if names != nil && len(names) != nn.Responses() {
+		panic("number of names does not match the number of output neurons")
+	}
+
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	nn.names = names
+}
+
+// Task returns the task of the network, [TaskClassification] by default.
+func (nn *NeuralNetwork) Task() Task {
+	nn.mu.RLock()
+	defer nn.mu.RUnlock()
+
+	return nn.task
+}
+
+// SetTask sets the task of the network, which changes the activation of the
+// output layer, but not its weights.
+func (nn *NeuralNetwork) SetTask(task Task) {
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	nn.task = task
+}
+
 // FeedForward computes the output of the neural network given an input vector.
 //
 // FeedForward panics if the input is not a matrix [n x 1] (a vector of length
@@ -125,7 +286,7 @@ func (nn *NeuralNetwork) feed_forward(comp *[]computation, input nnmath.Vector)
 	activation := (*comp)[len(nn.layers)-1].Activation
 
 	nnmath.AddMul(activation, last.Biases, last.Weights, input)
-	Softmax(activation)
+	nn.task.activate(activation)
 }
 
 type computation struct {
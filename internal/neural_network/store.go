@@ -2,6 +2,7 @@ package nn
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/alan-b-lima/nn-digits/pkg/mem"
 )
@@ -17,6 +18,9 @@ func (nn *NeuralNetwork) MarshalJSON() ([]byte, error) {
 	jn := neural_network{
 		Dimensions: nn.Dims(),
 		Layers:     nn.buf,
+		Classes:    nn.names,
+		Task:       nn.task,
+		Metadata:   nn.meta,
 	}
 
 	return json.Marshal(jn)
@@ -31,7 +35,14 @@ func (nn *NeuralNetwork) UnmarshalJSON(buf []byte) error {
 	nn.mu.Lock()
 	defer nn.mu.Unlock()
 
+	if jn.Classes != nil && len(jn.Dimensions) > 0 && len(jn.Classes) != jn.Dimensions[len(jn.Dimensions)-1] {
+		return errors.New("number of classes does not match the number of output neurons")
+	}
+
 	nn.buf = jn.Layers
+	nn.names = jn.Classes
+	nn.task = jn.Task
+	nn.meta = jn.Metadata
 
 	nn.layers = slice_nn(nn.buf, jn.Dimensions...)
 	nn.comp = mem.NewPool(nn.new_comp)
@@ -43,4 +54,7 @@ func (nn *NeuralNetwork) UnmarshalJSON(buf []byte) error {
 type neural_network struct {
 	Dimensions []int            `json:"dimensions"`
 	Layers     mem.Float64Slice `json:"layers"`
+	Classes    []string         `json:"classes,omitempty"`
+	Task       Task             `json:"task,omitempty"`
+	Metadata   Metadata         `json:"metadata,omitzero"`
 }
@@ -0,0 +1,105 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+// Task is the kind of problem a network solves, it determines the activation
+// of the output layer and how its outputs are scored.
+type Task uint8
+
+const (
+	// TaskClassification picks a single class out of many, the output layer
+	// is a softmax and the most probable class is the prediction.
+	TaskClassification Task = iota
+
+	// TaskMultiLabel picks any number of classes, each output is a sigmoid
+	// and every class whose output reaches [Threshold] is predicted.
+	TaskMultiLabel
+
+	// TaskRegression predicts continuous values, the output layer is the
+	// identity.
+	TaskRegression
+)
+
+// Threshold is the output from which a class is predicted in multi-label
+// tasks, as well as the label value from which a class is taken as present.
+const Threshold = .5
+
+func (t Task) String() string {
+	switch t {
+	case TaskClassification:
+		return "classification"
+	case TaskMultiLabel:
+		return "multilabel"
+	case TaskRegression:
+		return "regression"
+	}
+
+	return fmt.Sprintf("Task(%d)", uint8(t))
+}
+
+// ParseTask returns the task with the given name, "classification",
+// "multilabel" or "regression".
+func ParseTask(name string) (Task, error) {
+	switch name {
+	case "classification":
+		return TaskClassification, nil
+	case "multilabel", "multi-label":
+		return TaskMultiLabel, nil
+	case "regression":
+		return TaskRegression, nil
+	}
+
+	return 0, fmt.Errorf("unknown task %q", name)
+}
+
+func (t Task) MarshalText() ([]byte, error) {
+	switch t {
+	case TaskClassification, TaskMultiLabel, TaskRegression:
+		return []byte(t.String()), nil
+	}
+
+	return nil, fmt.Errorf("unknown task %d", uint8(t))
+}
+
+func (t *Task) UnmarshalText(text []byte) error {
+	task, err := ParseTask(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = task
+	return nil
+}
+
+// activate applies the output activation of the task onto vector.
+func (t Task) activate(vector nnmath.Vector) {
+	switch t {
+	case TaskClassification:
+		Softmax(vector)
+	case TaskMultiLabel:
+		nnmath.Apply(vector, vector, Sigmoid)
+	}
+}
+
+// derivative replaces the output activation in vector by the derivative of
+// the output activation of the task at it.
+func (t Task) derivative(vector nnmath.Vector) {
+	switch t {
+	case TaskClassification:
+		SoftmaxDerivativeFromActivation(vector)
+	case TaskMultiLabel:
+		nnmath.Apply(vector, vector, SigmoidDerivativeFromActivation)
+	case TaskRegression:
+		nnmath.Apply(vector, vector, func(float64) float64 { return 1 })
+	}
+}
+
+// predicted reports whether a class is predicted or present, given its output
+// or label value, in multi-label tasks.
+func predicted(v float64) bool {
+	return v >= Threshold
+}
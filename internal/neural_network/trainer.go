@@ -0,0 +1,240 @@
+package nn
+
+import (
+	"iter"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Observer is notified of the events of the training done by a [Trainer].
+// Observers are called synchronously, from the goroutine that trains, in the
+// order they were given, and may stop the training with [Trainer.Stop].
+type Observer interface {
+	OnBatchEnd(t *Trainer, e BatchEnd)
+	OnEpochEnd(t *Trainer, e EpochEnd)
+	OnEvaluate(t *Trainer, e Evaluated)
+	OnDivergence(t *Trainer, e Divergence)
+}
+
+// BatchEnd is sent after the network learned from a batch.
+type BatchEnd struct {
+	Cycle int
+	Batch Dataset
+	Size  int
+	Rate  float64
+
+	// Cost is the cost of the batch before the network learned from it, and
+	// GradientNorm the Euclidean norm of the gradient it learned.
+	Cost         float64
+	GradientNorm float64
+}
+
+// EpochEnd is sent after the network learned from every batch of an epoch.
+type EpochEnd struct {
+	Epoch   int
+	Cycle   int
+	Rate    float64
+	Batches int
+
+	// Cost and GradientNorm are the means of those of the batches.
+	Cost         float64
+	GradientNorm float64
+
+	Duration time.Duration
+}
+
+// Evaluated is sent after the network is evaluated against some datasets.
+type Evaluated struct {
+	Cycle int
+	Rate  float64
+
+	// Evaluations are the evaluations of the network against each dataset,
+	// by the name it was given.
+	Evaluations map[string]*Evaluation
+}
+
+// Divergence is sent, after [BatchEnd], when the cost or the norm of the
+// gradient of a batch is not finite, or the norm is over the maximum of the
+// trainer.
+type Divergence struct {
+	Cycle        int
+	Cost         float64
+	GradientNorm float64
+}
+
+// Hooks is an [Observer] that calls its non-nil functions. It may be embedded
+// by observers interested in only some events.
+type Hooks struct {
+	BatchEnd   func(*Trainer, BatchEnd)
+	EpochEnd   func(*Trainer, EpochEnd)
+	Evaluate   func(*Trainer, Evaluated)
+	Divergence func(*Trainer, Divergence)
+}
+
+func (h Hooks) OnBatchEnd(t *Trainer, e BatchEnd) {
+	if h.BatchEnd != nil {
+		h.BatchEnd(t, e)
+	}
+}
+
+func (h Hooks) OnEpochEnd(t *Trainer, e EpochEnd) {
+	if h.EpochEnd != nil {
+		h.EpochEnd(t, e)
+	}
+}
+
+func (h Hooks) OnEvaluate(t *Trainer, e Evaluated) {
+	if h.Evaluate != nil {
+		h.Evaluate(t, e)
+	}
+}
+
+func (h Hooks) OnDivergence(t *Trainer, e Divergence) {
+	if h.Divergence != nil {
+		h.Divergence(t, e)
+	}
+}
+
+// Trainer trains a network, batch by batch, notifying its observers of how
+// the training goes.
+type Trainer struct {
+	Network *NeuralNetwork
+	Rate    float64
+
+	// Cycle is the cycle the events are sent at. It is advanced by the caller
+	// of [Trainer.Batch], and once per batch by [Trainer.Epoch].
+	Cycle int
+
+	// MaxGradientNorm is the norm of the gradient over which the training is
+	// taken to diverge, none if zero.
+	MaxGradientNorm float64
+
+	Observers []Observer
+
+	stopped atomic.Bool
+}
+
+// Batch makes the network learn from the batch, and notifies the observers.
+func (t *Trainer) Batch(batch Dataset) BatchEnd {
+	cost, norm := t.Network.Step(batch, t.Rate)
+
+	e := BatchEnd{
+		Cycle:        t.Cycle,
+		Batch:        batch,
+		Size:         batch.Len(),
+		Rate:         t.Rate,
+		Cost:         cost,
+		GradientNorm: norm,
+	}
+	for _, o := range t.Observers {
+		o.OnBatchEnd(t, e)
+	}
+
+	diverged := math.IsNaN(cost) || math.IsInf(cost, 0) || math.IsNaN(norm) || math.IsInf(norm, 0)
+	if diverged || t.MaxGradientNorm > 0 && norm > t.MaxGradientNorm {
+		d := Divergence{Cycle: t.Cycle, Cost: cost, GradientNorm: norm}
+		for _, o := range t.Observers {
+			o.OnDivergence(t, d)
+		}
+	}
+
+	return e
+}
+
+// Epoch makes the network learn from every batch, advancing the cycle before
+// each, until they are over or the training is stopped, and notifies the
+// observers. The epoch is counted from one.
+func (t *Trainer) Epoch(epoch int, batches iter.Seq[Dataset]) EpochEnd {
+	start := time.Now()
+
+	e := EpochEnd{Epoch: epoch, Rate: t.Rate}
+	for batch := range batches {
+		if t.Stopped() {
+			break
+		}
+
+		t.Cycle++
+		b := t.Batch(batch)
+
+		e.Batches++
+		e.Cost += b.Cost
+		e.GradientNorm += b.GradientNorm
+	}
+
+	if e.Batches > 0 {
+		e.Cost /= float64(e.Batches)
+		e.GradientNorm /= float64(e.Batches)
+	}
+	e.Cycle = t.Cycle
+	e.Duration = time.Since(start)
+
+	for _, o := range t.Observers {
+		o.OnEpochEnd(t, e)
+	}
+
+	return e
+}
+
+// Evaluate evaluates the network against every non-empty dataset, see
+// [NeuralNetwork.Evaluate], and notifies the observers.
+func (t *Trainer) Evaluate(datasets map[string]Dataset, k int) Evaluated {
+	e := Evaluated{
+		Cycle:       t.Cycle,
+		Rate:        t.Rate,
+		Evaluations: make(map[string]*Evaluation, len(datasets)),
+	}
+	for name, dataset := range datasets {
+		if dataset != nil && dataset.Len() > 0 {
+			e.Evaluations[name] = t.Network.Evaluate(dataset, k)
+		}
+	}
+
+	for _, o := range t.Observers {
+		o.OnEvaluate(t, e)
+	}
+
+	return e
+}
+
+// Stop stops the training, it may be called from any goroutine.
+func (t *Trainer) Stop() {
+	t.stopped.Store(true)
+}
+
+// Stopped tells whether the training was stopped.
+func (t *Trainer) Stopped() bool {
+	return t.stopped.Load()
+}
+
+// EarlyStopping is an [Observer] that stops the training once the cost of the
+// evaluation against the dataset named Set has not decreased by more than
+// MinDelta for Patience evaluations in a row.
+type EarlyStopping struct {
+	Hooks
+
+	Set      string
+	Patience int
+	MinDelta float64
+
+	best  float64
+	stale int
+	seen  bool
+}
+
+func (s *EarlyStopping) OnEvaluate(t *Trainer, e Evaluated) {
+	eval, in := e.Evaluations[s.Set]
+	if !in || s.Patience <= 0 {
+		return
+	}
+
+	if !s.seen || eval.Cost < s.best-s.MinDelta {
+		s.best, s.stale, s.seen = eval.Cost, 0, true
+		return
+	}
+
+	s.stale++
+	if s.stale >= s.Patience {
+		t.Stop()
+	}
+}
@@ -1,13 +1,54 @@
 package nn
 
-import "github.com/alan-b-lima/nn-digits/pkg/nnmath"
+import (
+	"math"
+	"slices"
 
-func (nn *NeuralNetwork) Learn(dataset []Sample, rate float64) {
+	"github.com/alan-b-lima/nn-digits/pkg/nnmath"
+)
+
+func (nn *NeuralNetwork) Learn(dataset Dataset, rate float64) {
+	nn.Step(dataset, rate)
+}
+
+// Step is like [NeuralNetwork.Learn], but also returns the mean cost of the
+// dataset, before learning from it, and the Euclidean norm of the gradient.
+func (nn *NeuralNetwork) Step(dataset Dataset, rate float64) (cost, norm float64) {
 	comp, learn := nn.get_learn()
 	defer nn.free_learn(comp, learn)
 
-	nn.compute_gradient(comp, learn, dataset)
+	cost = nn.compute_gradient(comp, learn, dataset)
 	nn.apply_gradient(learn, rate)
+
+	for _, layer := range *learn {
+		for _, g := range layer.WeightGradient.Data() {
+			norm += g * g
+		}
+		for _, g := range layer.BiasGradient.Data() {
+			norm += g * g
+		}
+	}
+
+	return cost, math.Sqrt(norm)
+}
+
+// Gradient returns, for each layer but the input one, the gradient of the
+// cost over the dataset with respect to its weights and to its biases, as
+// [NeuralNetwork.Learn] would learn from, without learning from it.
+func (nn *NeuralNetwork) Gradient(dataset Dataset) (weights, biases [][]float64) {
+	comp, learn := nn.get_learn()
+	defer nn.free_learn(comp, learn)
+
+	nn.compute_gradient(comp, learn, dataset)
+
+	weights = make([][]float64, len(*learn))
+	biases = make([][]float64, len(*learn))
+	for i, layer := range *learn {
+		weights[i] = slices.Clone(layer.WeightGradient.Data())
+		biases[i] = slices.Clone(layer.BiasGradient.Data())
+	}
+
+	return weights, biases
 }
 
 func (nn *NeuralNetwork) apply_gradient(learn *[]learning, rate float64) {
@@ -22,12 +63,15 @@ func (nn *NeuralNetwork) apply_gradient(learn *[]learning, rate float64) {
 	}
 }
 
-func (nn *NeuralNetwork) compute_gradient(comp *[]computation, learn *[]learning, dataset []Sample) {
+// compute_gradient computes the gradient of the cost over the dataset onto
+// learn, and returns the cost.
+func (nn *NeuralNetwork) compute_gradient(comp *[]computation, learn *[]learning, dataset Dataset) (cost float64) {
 	if len(nn.layers) == 0 {
-		return
+		return 0
 	}
 
-	for _, sample := range dataset {
+	task := nn.Task()
+	for _, sample := range All(dataset) {
 		{
 			input := sample.Values
 			if len(nn.layers) > 1 {
@@ -38,8 +82,11 @@ func (nn *NeuralNetwork) compute_gradient(comp *[]computation, learn *[]learning
 			curr := (*learn)[len(*learn)-1]
 
 			nn.sample_cost_derivative(comp, curr.ErrorPropagation, sample)
+			for _, diff := range curr.ErrorPropagation.Data() {
+				cost += diff * diff
+			}
 
-			SoftmaxDerivativeFromActivation(activation)
+			task.derivative(activation)
 			nnmath.HMul(curr.ErrorPropagation, curr.ErrorPropagation, activation)
 
 			input_t := nnmath.Reshape(input, 1, input.Rows())
@@ -71,10 +118,12 @@ func (nn *NeuralNetwork) compute_gradient(comp *[]computation, learn *[]learning
 		}
 		nn.mu.RUnlock()
 
-		factor := 1 / float64(len(dataset))
+		factor := 1 / float64(dataset.Len())
 		for _, layer := range *learn {
 			nnmath.SMul(layer.WeightGradient, factor, layer.WeightGradient)
 			nnmath.SMul(layer.BiasGradient, factor, layer.BiasGradient)
 		}
 	}
+
+	return .5 * cost / float64(dataset.Len())
 }
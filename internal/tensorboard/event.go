@@ -0,0 +1,279 @@
+// Package tensorboard writes event files that TensorBoard reads: TFRecord
+// files of Event protocol buffers, with scalar and histogram summaries.
+//
+// The few messages needed are encoded by hand, after the definitions of
+// tensorflow/core/util/event.proto and tensorflow/core/framework/summary.proto.
+package tensorboard
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// histogram_buckets is the number of buckets of the histograms.
+const histogram_buckets = 30
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// files counts the event files created by the process.
+var files atomic.Int64
+
+// Value is a value of a summary, a histogram if Histogram is set, or a scalar
+// otherwise.
+type Value struct {
+	Tag       string
+	Scalar    float64
+	Histogram *Histogram
+}
+
+// Scalar returns a scalar value.
+func Scalar(tag string, v float64) Value {
+	return Value{Tag: tag, Scalar: v}
+}
+
+// HistogramOf returns a histogram value of the values.
+func HistogramOf(tag string, values []float64) Value {
+	return Value{Tag: tag, Histogram: NewHistogram(values, histogram_buckets)}
+}
+
+// Histogram is the distribution of some values. Counts[i] is the number of
+// values in the bucket from Limits[i-1], exclusive, or Min, up to Limits[i],
+// inclusive.
+type Histogram struct {
+	Min, Max   float64
+	Num        float64
+	Sum        float64
+	SumSquares float64
+
+	Limits []float64
+	Counts []float64
+}
+
+// NewHistogram returns the histogram of the values over evenly spaced
+// buckets, non-finite values are left out.
+func NewHistogram(values []float64, buckets int) *Histogram {
+	h := Histogram{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+
+		h.Min, h.Max = min(h.Min, v), max(h.Max, v)
+		h.Num++
+		h.Sum += v
+		h.SumSquares += v * v
+	}
+
+	if h.Num == 0 {
+		h.Min, h.Max = 0, 0
+		return &h
+	}
+	if h.Min == h.Max {
+		h.Limits, h.Counts = []float64{h.Max}, []float64{h.Num}
+		return &h
+	}
+
+	width := (h.Max - h.Min) / float64(buckets)
+
+	h.Limits = make([]float64, buckets)
+	h.Counts = make([]float64, buckets)
+	for i := range buckets {
+		h.Limits[i] = h.Min + float64(i+1)*width
+	}
+	h.Limits[buckets-1] = h.Max
+
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+
+		i := int(math.Ceil((v-h.Min)/width)) - 1
+		h.Counts[min(max(i, 0), buckets-1)]++
+	}
+
+	return &h
+}
+
+// Writer writes events onto an event file. Writer is safe for concurrent
+// use.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Create creates the directory, if needed, and an event file in it, named as
+// TensorBoard expects, and unique to the process and the call. The writer must
+// be closed.
+func Create(dir string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	// the pid and the counter tell apart the files created within the same
+	// second, by this process or others, as TensorFlow does
+	name := fmt.Sprintf("events.out.tfevents.%010d.%s.%d.%d", time.Now().Unix(), host, os.Getpid(), files.Add(1))
+
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// NewWriter returns a writer onto w, and writes the version of the event file,
+// which TensorBoard expects first.
+func NewWriter(w io.Writer) (*Writer, error) {
+	writer := Writer{w: w}
+
+	var event []byte
+	event = append_double(event, 1, wall_time(time.Now()))
+	event = append_bytes(event, 3, []byte("brain.Event:2"))
+
+	if err := writer.record(event); err != nil {
+		return nil, err
+	}
+
+	return &writer, nil
+}
+
+// Write writes an event with the values, at the given step.
+func (w *Writer) Write(step int, values ...Value) error {
+	var summary []byte
+	for _, v := range values {
+		summary = append_bytes(summary, 1, v.encode())
+	}
+
+	var event []byte
+	event = append_double(event, 1, wall_time(time.Now()))
+	event = append_varint(event, 2, uint64(step))
+	event = append_bytes(event, 5, summary)
+
+	return w.record(event)
+}
+
+// Close closes the underlying writer, if it is an [io.Closer].
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// record writes data as a TFRecord: its length, the masked CRC-32C of the
+// length, the data, and the masked CRC-32C of the data, all little-endian.
+func (w *Writer) record(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buf := make([]byte, 0, len(data)+16)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(data)))
+	buf = binary.LittleEndian.AppendUint32(buf, masked_crc(buf))
+	buf = append(buf, data...)
+	buf = binary.LittleEndian.AppendUint32(buf, masked_crc(data))
+
+	_, err := w.w.Write(buf)
+	return err
+}
+
+// encode encodes the value as a Summary.Value message.
+func (v Value) encode() []byte {
+	var b []byte
+	b = append_bytes(b, 1, []byte(v.Tag))
+
+	if v.Histogram == nil {
+		return append_float(b, 2, float32(v.Scalar))
+	}
+
+	h := v.Histogram
+
+	var histo []byte
+	histo = append_double(histo, 1, h.Min)
+	histo = append_double(histo, 2, h.Max)
+	histo = append_double(histo, 3, h.Num)
+	histo = append_double(histo, 4, h.Sum)
+	histo = append_double(histo, 5, h.SumSquares)
+	histo = append_doubles(histo, 6, h.Limits)
+	histo = append_doubles(histo, 7, h.Counts)
+
+	return append_bytes(b, 5, histo)
+}
+
+func masked_crc(data []byte) uint32 {
+	crc := crc32.Checksum(data, castagnoli)
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
+
+func wall_time(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+// Wire types of protocol buffers.
+const (
+	wire_varint  = 0
+	wire_fixed64 = 1
+	wire_bytes   = 2
+	wire_fixed32 = 5
+)
+
+func append_tag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func append_varint(b []byte, field int, v uint64) []byte {
+	b = append_tag(b, field, wire_varint)
+	return binary.AppendUvarint(b, v)
+}
+
+func append_double(b []byte, field int, v float64) []byte {
+	b = append_tag(b, field, wire_fixed64)
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+}
+
+func append_float(b []byte, field int, v float32) []byte {
+	b = append_tag(b, field, wire_fixed32)
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(v))
+}
+
+func append_bytes(b []byte, field int, v []byte) []byte {
+	b = append_tag(b, field, wire_bytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// append_doubles appends a packed repeated double field, if not empty.
+func append_doubles(b []byte, field int, vs []float64) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+
+	packed := make([]byte, 0, 8*len(vs))
+	for _, v := range vs {
+		packed = binary.LittleEndian.AppendUint64(packed, math.Float64bits(v))
+	}
+
+	return append_bytes(b, field, packed)
+}
@@ -0,0 +1,187 @@
+package tensorboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unhex decodes the hex string, ignoring spaces.
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestMaskedCRC(t *testing.T) {
+	// the CRC-32C of "123456789" is 0xe3069283
+	tests := []struct {
+		data []byte
+		want uint32
+	}{
+		{nil, 0xa282ead8},
+		{[]byte("123456789"), 0xc78ab0e5},
+		{make([]byte, 8), 0x07980329},
+	}
+
+	for _, tt := range tests {
+		if got := masked_crc(tt.data); got != tt.want {
+			t.Errorf("masked_crc(%q) = %#08x, want %#08x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	w := Writer{w: &buf}
+
+	if err := w.record([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	// length, masked CRC of the length, data, masked CRC of the data
+	want := unhex(t, "0300000000000000 b099490e 616263 6e57f121")
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("record:\ngot  % x\nwant % x", buf.Bytes(), want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Value
+		want  string
+	}{
+		{
+			name:  "scalar",
+			value: Scalar("loss", 0.5),
+			// tag = "loss", simple_value = 0.5
+			want: "0a 04 6c6f7373 15 0000003f",
+		},
+		{
+			name: "histogram",
+			value: Value{Tag: "h", Histogram: &Histogram{
+				Min: -1, Max: 1, Num: 2, Sum: 0, SumSquares: 2,
+				Limits: []float64{0, 1},
+				Counts: []float64{1, 1},
+			}},
+			// tag = "h", histo = {min, max, num, sum, sum_squares, packed
+			// bucket_limit, packed bucket}
+			want: "0a 01 68 2a 51" +
+				"09 000000000000f0bf" +
+				"11 000000000000f03f" +
+				"19 0000000000000040" +
+				"21 0000000000000000" +
+				"29 0000000000000040" +
+				"32 10 0000000000000000 000000000000f03f" +
+				"3a 10 000000000000f03f 000000000000f03f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := tt.value.encode(), unhex(t, tt.want); !bytes.Equal(got, want) {
+				t.Errorf("encode:\ngot  % x\nwant % x", got, want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(7, Scalar("loss", 0.5)); err != nil {
+		t.Fatal(err)
+	}
+
+	// wall_time, a double of field 1, is left out of the comparison
+	want := []string{
+		// file_version = "brain.Event:2"
+		"1a 0d 627261696e2e4576656e743a32",
+		// step = 7, summary = {value = {tag = "loss", simple_value = 0.5}}
+		"10 07 2a 0d 0a 0b 0a 04 6c6f7373 15 0000003f",
+	}
+
+	data := buf.Bytes()
+	for i, want := range want {
+		if len(data) < 12 {
+			t.Fatalf("event %d: truncated record", i)
+		}
+
+		n := binary.LittleEndian.Uint64(data)
+		if crc := binary.LittleEndian.Uint32(data[8:]); crc != masked_crc(data[:8]) {
+			t.Errorf("event %d: bad length CRC %#08x", i, crc)
+		}
+
+		event := data[12 : 12+n]
+		if crc := binary.LittleEndian.Uint32(data[12+n:]); crc != masked_crc(event) {
+			t.Errorf("event %d: bad data CRC %#08x", i, crc)
+		}
+		data = data[12+n+4:]
+
+		if event[0] != 0x09 {
+			t.Fatalf("event %d: expected wall_time first, got % x", i, event)
+		}
+		if got, want := event[9:], unhex(t, want); !bytes.Equal(got, want) {
+			t.Errorf("event %d:\ngot  % x\nwant % x", i, got, want)
+		}
+	}
+
+	if len(data) != 0 {
+		t.Errorf("%d bytes left after the events", len(data))
+	}
+}
+
+func TestNewHistogram(t *testing.T) {
+	got := NewHistogram([]float64{-1, 0, 1}, 2)
+	want := &Histogram{
+		Min: -1, Max: 1, Num: 3, Sum: 0, SumSquares: 2,
+		Limits: []float64{0, 1},
+		Counts: []float64{2, 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewHistogram:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	// files created within the same second must not collide
+	for range 3 {
+		w, err := Create(dir)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d event files, want 3", len(entries))
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "events.out.tfevents.") {
+			t.Errorf("unexpected file name %q", e.Name())
+		}
+	}
+}
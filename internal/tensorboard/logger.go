@@ -0,0 +1,176 @@
+package tensorboard
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// Logger is an [nn.Observer] that writes how the training goes onto an event
+// file:
+//   - the mean cost and gradient norm of the batches of every cycle, as
+//     train/batch_cost and train/gradient_norm;
+//   - on every evaluation, the cost and accuracy against each dataset, as
+//     <dataset>/cost and <dataset>/accuracy, and the learning rate, as
+//     train/learning_rate;
+//   - on every evaluation, at the end of every epoch and every Every cycles,
+//     histograms of the weights and biases of every layer, and of their
+//     gradients against the last batch, as layer_<i>/weights,
+//     layer_<i>/biases, layer_<i>/weight_gradients and
+//     layer_<i>/bias_gradients.
+//
+// The step of the events is the cycle.
+type Logger struct {
+	nn.Hooks
+
+	// Every is how many cycles apart histograms are written at least, on
+	// top of evaluations and epochs, none if zero.
+	Every int
+
+	mu sync.Mutex
+	w  *Writer
+
+	// cycle is the cycle of the batches not yet written, batches is their
+	// count, cost and norm, the sums of their costs and gradient norms, and
+	// batch, the last of them.
+	cycle   int
+	batches int
+	cost    float64
+	norm    float64
+	batch   nn.Dataset
+
+	// histograms is the cycle the last histograms were written at.
+	histograms int
+
+	err error
+}
+
+// NewLogger returns a logger that writes onto w.
+func NewLogger(w *Writer) *Logger {
+	return &Logger{w: w, histograms: -1}
+}
+
+func (l *Logger) OnBatchEnd(t *nn.Trainer, e nn.BatchEnd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.batches > 0 && e.Cycle != l.cycle {
+		l.flush()
+	}
+
+	l.cycle = e.Cycle
+	l.batches++
+	l.cost += e.Cost
+	l.norm += e.GradientNorm
+	l.batch = e.Batch
+
+	if l.Every > 0 && (l.histograms < 0 || e.Cycle-l.histograms >= l.Every) {
+		l.write_histograms(t.Network, e.Cycle)
+	}
+}
+
+func (l *Logger) OnEpochEnd(t *nn.Trainer, e nn.EpochEnd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush()
+	l.write_histograms(t.Network, e.Cycle)
+}
+
+func (l *Logger) OnEvaluate(t *nn.Trainer, e nn.Evaluated) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush()
+
+	var values []Value
+	for _, name := range slices.Sorted(maps.Keys(e.Evaluations)) {
+		eval := e.Evaluations[name]
+
+		values = append(values, Scalar(name+"/cost", eval.Cost))
+		if eval.Task != nn.TaskRegression {
+			values = append(values, Scalar(name+"/accuracy", eval.Accuracy()))
+		}
+	}
+	values = append(values, Scalar("train/learning_rate", e.Rate))
+
+	l.write(e.Cycle, values...)
+	l.write_histograms(t.Network, e.Cycle)
+}
+
+// Err returns the first error writing the events, if any.
+func (l *Logger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Close writes the batches not yet written, closes the writer and returns the
+// first error writing the events, if any.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush()
+	if err := l.w.Close(); l.err == nil {
+		l.err = err
+	}
+
+	return l.err
+}
+
+// flush writes the means of the batches not yet written, if any.
+func (l *Logger) flush() {
+	if l.batches == 0 {
+		return
+	}
+
+	n := float64(l.batches)
+	l.write(l.cycle,
+		Scalar("train/batch_cost", l.cost/n),
+		Scalar("train/gradient_norm", l.norm/n),
+	)
+
+	l.batches, l.cost, l.norm = 0, 0, 0
+}
+
+// write_histograms writes the histograms of the network, unless they were
+// already written at this cycle.
+func (l *Logger) write_histograms(network *nn.NeuralNetwork, cycle int) {
+	if cycle == l.histograms {
+		return
+	}
+	l.histograms = cycle
+
+	var weight_gradients, bias_gradients [][]float64
+	if l.batch != nil && l.batch.Len() > 0 {
+		weight_gradients, bias_gradients = network.Gradient(l.batch)
+	}
+
+	var values []Value
+	for i := range network.Len() - 1 {
+		tag := fmt.Sprintf("layer_%d/", i+1)
+
+		weights, biases := network.Layer(i)
+		values = append(values, HistogramOf(tag+"weights", weights), HistogramOf(tag+"biases", biases))
+
+		if weight_gradients != nil {
+			values = append(values,
+				HistogramOf(tag+"weight_gradients", weight_gradients[i]),
+				HistogramOf(tag+"bias_gradients", bias_gradients[i]),
+			)
+		}
+	}
+
+	l.write(cycle, values...)
+}
+
+func (l *Logger) write(step int, values ...Value) {
+	if err := l.w.Write(step, values...); err != nil && l.err == nil {
+		l.err = err
+	}
+}
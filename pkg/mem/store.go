@@ -1,12 +1,17 @@
 package mem
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"math"
 )
 
+// Float64Slice is a slice of float64 that is marshaled to JSON as the base64
+// encoding of its little endian IEEE 754 representation. It may also be
+// unmarshaled from a plain JSON array of numbers.
 type Float64Slice []float64
 
 func (m Float64Slice) MarshalJSON() ([]byte, error) {
@@ -22,6 +27,10 @@ func (m Float64Slice) MarshalJSON() ([]byte, error) {
 }
 
 func (m *Float64Slice) UnmarshalJSON(buf []byte) error {
+	if trimmed := bytes.TrimSpace(buf); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, (*[]float64)(m))
+	}
+
 	if len(buf) < 2 || buf[0] != '"' || buf[len(buf)-1] != '"' {
 		return errors.New("matrix must be a well-formed JSON string")
 	}
@@ -0,0 +1,185 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alan-b-lima/nn-digits/internal/augment"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandAugment(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if len(args) < 1 {
+		if len(ctx.Augmentation) == 0 {
+			fmt.Fprintln(w, "No augmentation.")
+		}
+		for i, t := range ctx.Augmentation {
+			fmt.Fprintf(w, "%d: %s\n", i, t)
+		}
+		return nil
+	}
+
+	switch directive := args[0]; directive {
+	case "add":
+		t, err := parse_transform(args[1:]...)
+		if err != nil {
+			return err
+		}
+
+		ctx.Augmentation = append(ctx.Augmentation, t)
+
+	case "remove":
+		if len(args) < 2 {
+			return ErrAugmentMissingArgs
+		}
+
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return ErrBadNumber(err)
+		}
+		if index < 0 || len(ctx.Augmentation) <= index {
+			return ErrOutOfRange(index, len(ctx.Augmentation))
+		}
+
+		ctx.Augmentation = slices.Delete(slices.Clone(ctx.Augmentation), index, index+1)
+
+	case "clear":
+		ctx.Augmentation = nil
+
+	case "preview":
+		if len(args) < 2 {
+			return ErrAugmentMissingArgs
+		}
+
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return ErrBadNumber(err)
+		}
+		if index < 0 || ctx.Training.Len() <= index {
+			return ErrOutOfRange(index, ctx.Training.Len())
+		}
+
+		original := ctx.Training.Get(index).Values
+		side := image_side(original.Size())
+		augmented := augment.Dataset(nn.Slice(ctx.Training, index, index+1), ctx.Augmentation)
+
+		blocks := [][]string{Image(original.Data(), side, ImageBlocks)}
+		for range 3 {
+			blocks = append(blocks, Image(augmented.Get(0).Values.Data(), side, ImageBlocks))
+		}
+
+		io.WriteString(w, Columns(blocks, 2))
+
+	default:
+		return ErrUnknownDirective(directive)
+	}
+
+	return nil
+}
+
+// unlabel_params turns a transform, as formatted by its String method, back
+// into the arguments of parse_transform, e.g., "noise sigma=0.1" into "noise"
+// and "0.1".
+func unlabel_params(transform string) []string {
+	args := strings.Fields(transform)
+	for i, arg := range args {
+		if _, value, ok := strings.Cut(arg, "="); ok {
+			args[i] = value
+		}
+	}
+
+	return args
+}
+
+// Upper bounds of the parameters of the transforms, past which they would
+// either be meaningless or take too long to apply.
+const (
+	max_translate  = 8
+	max_alpha      = 64
+	max_sigma      = 8
+	max_morphology = 4
+)
+
+func parse_transform(args ...string) (augment.Transform, error) {
+	if len(args) < 1 {
+		return nil, ErrAugmentMissingArgs
+	}
+
+	params := make([]float64, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		param, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, ErrBadNumber(err)
+		}
+
+		params = append(params, param)
+	}
+
+	// fills missing parameters with the defaults
+	with := func(defaults ...float64) []float64 {
+		return append(params, defaults[min(len(params), len(defaults)):]...)
+	}
+
+	kind := args[0]
+
+	// checks that the parameter lies in [lo, hi], keeping the first error
+	var err error
+	in := func(name string, v, lo, hi float64) float64 {
+		if err == nil && (!(lo <= v && v <= hi) || math.IsInf(v, 0)) {
+			err = ErrBadParameter(kind+" "+name, lo, hi)
+		}
+		return v
+	}
+
+	var t augment.Transform
+	switch kind {
+	case "affine":
+		p := with(10, .1, .1, 2)
+		t = augment.Affine{
+			Rotate:    in("rotate", p[0], 0, 180),
+			Scale:     in("scale", p[1], 0, .9),
+			Shear:     in("shear", p[2], 0, 1),
+			Translate: in("translate", p[3], 0, max_translate),
+		}
+
+	case "elastic":
+		p := with(8, 3)
+		t = augment.Elastic{Alpha: in("alpha", p[0], 0, max_alpha), Sigma: in("sigma", p[1], 0, max_sigma)}
+
+	case "noise":
+		p := with(.05)
+		t = augment.Noise{Sigma: in("sigma", p[0], 0, 1)}
+
+	case "morphology":
+		p := with(1)
+		if in("max", p[0], 0, max_morphology) != math.Trunc(p[0]) && err == nil {
+			err = ErrBadInteger(kind + " max")
+		}
+		t = augment.Morphology{Max: int(p[0])}
+
+	case "erase":
+		p := with(.5, .1)
+		t = augment.Erase{Probability: in("probability", p[0], 0, 1), Area: in("area", p[1], 0, 1)}
+
+	default:
+		return nil, ErrUnknownDirective(kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/alan-b-lima/nn-digits/internal/digits"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandClassify(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrClassifyMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if e, i := ctx.NeuralNetwork.Features(), len(digits.Request{}); e != i {
+		return ErrBadInput(e, i)
+	}
+
+	var deskew bool
+	if len(args) >= 2 {
+		if args[1] != "deskew" {
+			return ErrUnknownDirective(args[1])
+		}
+		deskew = true
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req, err := digits.DecodeImage(f)
+	if err != nil {
+		return err
+	}
+	req = digits.Preprocess(req, deskew)
+
+	probs, err := digits.NewClassifier(ctx.NeuralNetwork, digits.WithoutPreprocessing()).Classify(req)
+	if err != nil {
+		return err
+	}
+
+	names := ctx.NeuralNetwork.Classes()
+
+	classes := make([]int, len(probs))
+	for i := range classes {
+		classes[i] = i
+	}
+
+	var lines []string
+	switch ctx.NeuralNetwork.Task() {
+	case nn.TaskRegression:
+		for _, class := range classes {
+			lines = append(lines, fmt.Sprintf("%s: %g", names.Name(class), probs[class]))
+		}
+
+	default:
+		slices.SortFunc(classes, func(a, b int) int {
+			return cmp.Compare(probs[b], probs[a])
+		})
+
+		for _, class := range classes {
+			lines = append(lines, fmt.Sprintf("%s: %6.2f%%", names.Name(class), 100*probs[class]))
+		}
+	}
+
+	io.WriteString(w, Columns([][]string{Image(req[:], digits.Side, ImageBlocks), lines}, 2))
+	return nil
+}
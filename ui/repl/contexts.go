@@ -0,0 +1,331 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandClone(state *State, w io.Writer, r io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrCloneMissingArgs
+	}
+
+	src, in := state.ctxs[args[0]]
+	if !in {
+		return ErrContextNotFound
+	}
+
+	name := args[1]
+	if !reName.MatchString(name) {
+		return ErrBadName
+	}
+	if state.busy(name) {
+		return ErrContextBusy
+	}
+
+	if ctx, in := state.ctxs[name]; in && ctx != src && ctx.unsaved() {
+		overwrite, err := state.overwrite(w, r, name)
+		if err != nil || !overwrite {
+			return nil
+		}
+	}
+
+	src.mu.Lock()
+	ctx := src.Clone()
+	src.mu.Unlock()
+
+	ctx.Unsaved = true
+	state.put(name, ctx)
+
+	state.focus = name
+	return nil
+}
+
+func CommandRename(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrRenameMissingArgs
+	}
+	from, to := args[0], args[1]
+
+	ctx, in := state.ctxs[from]
+	if !in {
+		return ErrContextNotFound
+	}
+
+	if !reName.MatchString(to) {
+		return ErrBadName
+	}
+	if _, in := state.ctxs[to]; in {
+		return ErrContextExists
+	}
+	if state.busy(from) {
+		return ErrContextBusy
+	}
+
+	delete(state.ctxs, from)
+	state.ctxs[to] = ctx
+
+	ctx.mu.Lock()
+	if ctx.log != nil {
+		ctx.log.Context = to
+	}
+	ctx.mu.Unlock()
+
+	if state.focus == from {
+		state.focus = to
+	}
+	return nil
+}
+
+func CommandDrop(state *State, w io.Writer, r io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrDropMissingArgs
+	}
+	name := args[0]
+
+	ctx, in := state.ctxs[name]
+	if !in {
+		return ErrContextNotFound
+	}
+	if state.busy(name) {
+		return ErrContextBusy
+	}
+
+	if ctx.unsaved() {
+		drop, err := state.confirm(w, r, fmt.Sprintf("There are unsaved changes, do you want to drop %q", name))
+		if err != nil || !drop {
+			return nil
+		}
+	}
+
+	delete(state.ctxs, name)
+
+	if state.focus == name {
+		state.focus = ""
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	ctx.close_files()
+
+	if err := ctx.close_log(); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	if err := ctx.close_board(); err != nil {
+		return fmt.Errorf("tensorboard: %w", err)
+	}
+	return nil
+}
+
+func CommandCompare(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrCompareMissingArgs
+	}
+	names := args[:2]
+
+	a, in := state.ctxs[names[0]]
+	if !in {
+		return ErrContextNotFound
+	}
+	b, in := state.ctxs[names[1]]
+	if !in {
+		return ErrContextNotFound
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if b != a {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+
+	set := "tests"
+	if len(args) >= 3 {
+		set = args[2]
+	}
+
+	data, err := a.Dataset(set)
+	if err != nil {
+		return err
+	}
+	if data.Len() == 0 {
+		return ErrEmptyDataset
+	}
+
+	first := data.Get(0)
+	if e, i := b.NeuralNetwork.Features(), first.Values.Rows(); e != i {
+		return ErrBadInput(e, i)
+	}
+	if e, o := b.NeuralNetwork.Responses(), first.Label.Rows(); e != o {
+		return ErrBadOutput(e, o)
+	}
+
+	ctxs := []*Context{a, b}
+	evals := []*nn.Evaluation{
+		a.NeuralNetwork.Evaluate(data, 3),
+		b.NeuralNetwork.Evaluate(data, 3),
+	}
+
+	t := table{columns: names}
+	t.row("Cycles", func(i int) string { return fmt.Sprint(ctxs[i].Cycle) })
+	t.row("Learning rate", func(i int) string { return fmt.Sprintf("%g", ctxs[i].LearningRate) })
+
+	switch a.NeuralNetwork.Task() {
+	case nn.TaskRegression:
+		t.row("MSE", func(i int) string { return fmt.Sprintf("%f", evals[i].MSE()) })
+		t.row("MAE", func(i int) string { return fmt.Sprintf("%f", evals[i].MAE) })
+		t.row("R²", func(i int) string { return fmt.Sprintf("%f", evals[i].R2) })
+
+	case nn.TaskClassification:
+		t.row("Accuracy", func(i int) string { return fmt.Sprintf("%.2f%%", 100*evals[i].Accuracy()) })
+		t.row("Top-3 accuracy", func(i int) string { return fmt.Sprintf("%.2f%%", 100*evals[i].TopKAccuracy()) })
+		t.row("Log-loss", func(i int) string { return fmt.Sprintf("%f", evals[i].LogLoss) })
+		t.row("Macro F1", func(i int) string { _, _, f1 := evals[i].Macro(); return fmt.Sprintf("%.4f", f1) })
+
+	case nn.TaskMultiLabel:
+		t.row("Accuracy", func(i int) string { return fmt.Sprintf("%.2f%%", 100*evals[i].Accuracy()) })
+		t.row("Hamming loss", func(i int) string { return fmt.Sprintf("%.4f", evals[i].HammingLoss()) })
+		t.row("Macro F1", func(i int) string { _, _, f1 := evals[i].Macro(); return fmt.Sprintf("%.4f", f1) })
+	}
+	t.row("Cost", func(i int) string { return fmt.Sprintf("%f", evals[i].Cost) })
+
+	fmt.Fprintf(w, "On %s, %d samples:\n\n", set, data.Len())
+	t.write(w)
+
+	dims := a.NeuralNetwork.Dims()
+	if !slices.Equal(dims, b.NeuralNetwork.Dims()) {
+		fmt.Fprintln(w, "\nThe dimensions differ, the weights cannot be compared.")
+		return nil
+	}
+
+	weights, biases := a.NeuralNetwork.Distance(b.NeuralNetwork)
+	distances := [][]float64{weights, biases}
+
+	d := table{columns: []string{"Weights", "Biases"}}
+	for i := range weights {
+		d.row(fmt.Sprintf("Layer %d (%d×%d)", i+1, dims[i+1], dims[i]), func(j int) string {
+			return fmt.Sprintf("%f", distances[j][i])
+		})
+	}
+
+	fmt.Fprint(w, "\nDistance between the parameters:\n\n")
+	d.write(w)
+	return nil
+}
+
+// Clone returns a copy of the context, with a copy of its network. The
+// datasets, along with the files they are read from, and the parameters of the
+// snapshots are shared, as they are never modified, only replaced.
+func (ctx *Context) Clone() *Context {
+	clone := Context{
+		NeuralNetwork: ctx.NeuralNetwork.Clone(),
+		Training:      ctx.Training,
+		Tests:         ctx.Tests,
+		Validation:    ctx.Validation,
+		LearningRate:  ctx.LearningRate,
+		BatchSize:     ctx.BatchSize,
+		Augmentation:  slices.Clone(ctx.Augmentation),
+		Cycle:         ctx.Cycle,
+		Evolution:     slices.Clone(ctx.Evolution),
+		Sources:       slices.Clone(ctx.Sources),
+		Snapshots:     slices.Clone(ctx.Snapshots),
+		undo:          slices.Clone(ctx.undo),
+		Unsaved:       ctx.Unsaved,
+	}
+
+	for _, f := range ctx.files {
+		f.refs.Add(1)
+		clone.files = append(clone.files, f)
+	}
+
+	return &clone
+}
+
+// shared_file is a file some datasets are read from, such as a memory-mapped
+// one, shared by the contexts that hold them, it is closed once the last of
+// them releases it.
+type shared_file struct {
+	io.Closer
+	refs atomic.Int32
+}
+
+// hold makes the context hold the file data is read from, if it has one.
+func (ctx *Context) hold(data nn.Dataset) {
+	if c, ok := data.(io.Closer); ok {
+		f := &shared_file{Closer: c}
+		f.refs.Store(1)
+		ctx.files = append(ctx.files, f)
+	}
+}
+
+// close_files releases the files the datasets of the context are read from,
+// closing those no other context holds. The datasets must no longer be used.
+func (ctx *Context) close_files() {
+	for _, f := range ctx.files {
+		if f.refs.Add(-1) == 0 {
+			f.Close()
+		}
+	}
+
+	ctx.files = nil
+}
+
+// table is a table with a column per context, or metric, and labelled rows,
+// see [table.row].
+type table struct {
+	columns []string
+	labels  []string
+	cells   [][]string
+}
+
+// row adds a row, whose cell in each column is given by cell.
+func (t *table) row(label string, cell func(column int) string) {
+	cells := make([]string, len(t.columns))
+	for i := range cells {
+		cells[i] = cell(i)
+	}
+
+	t.labels = append(t.labels, label)
+	t.cells = append(t.cells, cells)
+}
+
+func (t *table) write(w io.Writer) {
+	label_width := 0
+	for _, label := range t.labels {
+		label_width = max(label_width, utf8.RuneCountInString(label))
+	}
+
+	widths := make([]int, len(t.columns))
+	for i, column := range t.columns {
+		widths[i] = utf8.RuneCountInString(column)
+		for _, cells := range t.cells {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cells[i]))
+		}
+	}
+
+	var b strings.Builder
+
+	b.WriteString(strings.Repeat(" ", label_width))
+	for i, column := range t.columns {
+		b.WriteString("  " + pad_left(column, widths[i]))
+	}
+	b.WriteByte('\n')
+
+	for i, label := range t.labels {
+		b.WriteString(label + strings.Repeat(" ", label_width-utf8.RuneCountInString(label)))
+		for j, cell := range t.cells[i] {
+			b.WriteString("  " + pad_left(cell, widths[j]))
+		}
+		b.WriteByte('\n')
+	}
+
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,471 @@
+package repl
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// history_file is where the history is kept, under the home directory, and
+// max_history, how many lines of it are kept in memory.
+const (
+	history_file = ".nn_digits_history"
+	max_history  = 1000
+)
+
+// Keys the callback of the terminal handles, besides printable ones. Those
+// the terminal would handle itself are translated into runes of the private
+// use area by key_filter, so that the callback gets them instead.
+const (
+	key_cancel = 0x07 // ^G
+	key_tab    = 0x09
+	key_search = 0x12 // ^R
+)
+
+const (
+	key_backspace = 0xe000 + iota
+	key_interrupt
+)
+
+// line_reader reads directives, one line at a time.
+type line_reader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plain_reader reads lines as they come, only writing the prompt if the REPL
+// is interactive.
+type plain_reader struct {
+	*bufio.Reader
+
+	w           io.Writer
+	interactive bool
+}
+
+func (p plain_reader) ReadLine(prompt string) (string, error) {
+	if p.interactive {
+		fmt.Fprint(p.w, prompt)
+	}
+
+	line, err := p.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+// editor reads lines from a terminal with cursor movement, a history, which
+// is searched backwards with ^R, and tab completion. The terminal is only in
+// raw mode while reading, so that directives run with it as usual.
+type editor struct {
+	state *State
+	fd    int
+	w     io.Writer
+
+	terminal *term.Terminal
+
+	// entries are the lines of the history, the most recent last, which
+	// are also appended onto file, if it could be opened.
+	entries []string
+	file    *os.File
+
+	// searching tells whether a backwards search is in progress, for
+	// query, whose current match is entries[len(entries)-1-index], and
+	// shown is the line shown while searching. original is the line as it
+	// was before searching.
+	searching bool
+	query     string
+	index     int
+	match     string
+	shown     string
+	original  string
+}
+
+func new_editor(state *State, w io.Writer, r io.Reader) (*editor, bool) {
+	f, ok := r.(interface{ Fd() uintptr })
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return nil, false
+	}
+
+	e := editor{state: state, fd: int(f.Fd()), w: w}
+
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{&key_filter{r: r}, w}
+
+	e.terminal = term.NewTerminal(rw, "")
+	e.terminal.History = &e
+	e.terminal.AutoCompleteCallback = e.key
+
+	if home, err := os.UserHomeDir(); err == nil {
+		e.load_history(filepath.Join(home, history_file))
+	}
+
+	return &e, true
+}
+
+// Close closes the history file.
+func (e *editor) Close() error {
+	if e.file == nil {
+		return nil
+	}
+
+	return e.file.Close()
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	old, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(e.fd, old)
+
+	if width, height, ok := term_size(e.w); ok && width > 0 {
+		e.terminal.SetSize(width, height)
+	}
+	e.terminal.SetPrompt(prompt)
+
+	line, err := e.terminal.ReadLine()
+	if e.searching {
+		line = e.match
+		e.searching = false
+	}
+
+	return line, err
+}
+
+func (e *editor) load_history(path string) {
+	if data, err := os.ReadFile(path); err == nil {
+		for line := range strings.Lines(string(data)) {
+			if line = strings.TrimRight(line, "\r\n"); line != "" {
+				e.entries = append(e.entries, line)
+			}
+		}
+		e.entries = e.entries[max(len(e.entries)-max_history, 0):]
+	}
+
+	e.file, _ = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+}
+
+// Add implements [term.History], lines are added unless blank or the same as
+// the last one.
+func (e *editor) Add(line string) {
+	if e.searching {
+		line = e.match
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(e.entries); n > 0 && e.entries[n-1] == line {
+		return
+	}
+
+	e.entries = append(e.entries, line)
+	if len(e.entries) > max_history {
+		e.entries = slices.Delete(e.entries, 0, len(e.entries)-max_history)
+	}
+
+	if e.file != nil {
+		fmt.Fprintln(e.file, line)
+	}
+}
+
+// Len implements [term.History].
+func (e *editor) Len() int {
+	return len(e.entries)
+}
+
+// At implements [term.History], 0 is the most recent line.
+func (e *editor) At(i int) string {
+	return e.entries[len(e.entries)-1-i]
+}
+
+// key is the callback of the terminal, called for every key it does not
+// handle itself.
+func (e *editor) key(line string, pos int, key rune) (string, int, bool) {
+	// any key the terminal handled, e.g., the arrows, ends the search
+	if e.searching && line != e.shown {
+		e.searching = false
+	}
+
+	if e.searching {
+		switch {
+		case key == key_search:
+			e.search(e.index + 1)
+
+		case key == key_backspace:
+			if e.query != "" {
+				_, size := utf8.DecodeLastRuneInString(e.query)
+				e.query = e.query[:len(e.query)-size]
+			}
+			e.search(0)
+
+		case key == key_cancel || key == key_interrupt:
+			e.searching = false
+			return e.original, len(e.original), true
+
+		case unicode.IsPrint(key):
+			e.query += string(key)
+			e.search(max(e.index, 0))
+
+		default:
+			e.searching = false
+			return e.match, len(e.match), true
+		}
+
+		return e.shown, len(e.shown) - len(e.match) + max(strings.Index(e.match, e.query), 0), true
+	}
+
+	switch key {
+	case key_search:
+		e.searching = true
+		e.query, e.index, e.match, e.original = "", -1, "", line
+		e.search(0)
+		return e.shown, len(e.shown), true
+
+	case key_backspace:
+		if pos == 0 {
+			return line, pos, true
+		}
+		_, size := utf8.DecodeLastRuneInString(line[:pos])
+		return line[:pos-size] + line[pos:], pos - size, true
+
+	case key_interrupt:
+		return "", 0, true
+
+	case key_tab:
+		return e.complete(line, pos)
+	}
+
+	return "", 0, false
+}
+
+// search looks for the query backwards from the from-th most recent line,
+// keeping the current match if there is none.
+func (e *editor) search(from int) {
+	failed := true
+	if e.query != "" {
+		for i := from; i < len(e.entries); i++ {
+			if entry := e.At(i); strings.Contains(entry, e.query) {
+				e.index, e.match, failed = i, entry, false
+				break
+			}
+		}
+	}
+
+	prompt := "(reverse-i-search)"
+	if failed && e.query != "" {
+		prompt = "(failed reverse-i-search)"
+	}
+	e.shown = fmt.Sprintf("%s`%s': %s", prompt, e.query, e.match)
+}
+
+// complete completes the word before the cursor, with directive names if it
+// is the first one, or with what the directive takes otherwise. If there are
+// several candidates, the word is completed as far as they agree, and they
+// are listed if it could not be.
+func (e *editor) complete(line string, pos int) (string, int, bool) {
+	head := line[:pos]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	args := strings.Fields(head[:start])
+
+	var candidates []string
+	if len(args) == 0 {
+		candidates = slices.Sorted(maps.Keys(directives))
+	} else {
+		candidates = e.arguments(args, word)
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+
+	var completion string
+	switch len(matches) {
+	case 0:
+		return line, pos, true
+
+	case 1:
+		completion = matches[0]
+		if !strings.HasSuffix(completion, "/") {
+			completion += " "
+		}
+
+	default:
+		completion = common_prefix(matches)
+		if completion == word {
+			names := make([]string, len(matches))
+			for i, m := range matches {
+				names[i] = filepath.Base(m)
+				if strings.HasSuffix(m, "/") {
+					names[i] += "/"
+				}
+			}
+			fmt.Fprintln(e.terminal, strings.Join(names, "  "))
+		}
+	}
+
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+// arguments returns the candidates for the next argument of a directive:
+// contexts for those taking them, the keywords it takes as its first argument, if any,
+// or otherwise file paths.
+func (e *editor) arguments(args []string, word string) []string {
+	directive := args[0]
+
+	switch {
+	case directive == "focus" && len(args) == 1,
+		directive == "clone" && len(args) == 1,
+		directive == "rename" && len(args) == 1,
+		directive == "drop" && len(args) == 1,
+		directive == "compare" && len(args) <= 2,
+		directive == "load" && len(args) == 2 && args[1] == "model":
+		return slices.Sorted(maps.Keys(e.state.ctxs))
+	}
+	if directive == "revert" && len(args) == 1 {
+		return e.snapshots()
+	}
+	if directive == "graph" && len(args) >= 2 && args[1] == "show" {
+		return slices.Sorted(maps.Keys(screen_metrics))
+	}
+	if directive == "graph" && len(args) == 2 && args[1] == "scale" {
+		return []string{"linear", "log"}
+	}
+	if keywords, in := keywords[directive]; in && len(args) == 1 {
+		return keywords
+	}
+
+	return paths(word)
+}
+
+// snapshots returns the names of the snapshots of the focused context.
+func (e *editor) snapshots() []string {
+	ctx := e.state.Focused()
+	if ctx == nil {
+		return nil
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	names := make([]string, len(ctx.Snapshots))
+	for i, s := range ctx.Snapshots {
+		names[i] = s.Name
+	}
+
+	return names
+}
+
+// keywords are the keywords directives take as their first argument.
+var keywords = map[string][]string{
+	"load":        {"model", "training", "tests", "validation"},
+	"store":       {"model"},
+	"evaluate":    {"training", "tests", "validation"},
+	"show":        {"training", "tests", "validation"},
+	"inspect":     {"training", "tests", "validation"},
+	"task":        {"classification", "multilabel", "regression"},
+	"session":     {"save", "load"},
+	"augment":     {"add", "remove", "clear", "preview"},
+	"set":         {"-e", "+e"},
+	"bg":          {"train", "cycle"},
+	"graph":       {"show", "scale", "smooth"},
+	"plot":        {"export"},
+	"log":         {"off"},
+	"tensorboard": {"off"},
+	"jobs":        {},
+	"snapshots":   {},
+	"undo":        {},
+	"help":        {},
+	"list":        {},
+	"status":      {},
+	"info":        {},
+	"clear":       {},
+	"exit":        {},
+	"quit":        {},
+}
+
+// paths returns the files and directories whose path starts with prefix, the
+// latter with a trailing slash. Hidden ones are left out, unless asked for.
+func paths(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+
+	entries, err := os.ReadDir(cmp.Or(dir, "."))
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		path := dir + name
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func common_prefix(s []string) string {
+	prefix := s[0]
+	for _, str := range s[1:] {
+		for !strings.HasPrefix(str, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+// key_filter translates the keys the terminal would otherwise handle itself,
+// but the editor handles differently, into runes of the private use area.
+type key_filter struct {
+	r       io.Reader
+	pending []byte
+}
+
+func (f *key_filter) Read(p []byte) (int, error) {
+	if len(f.pending) == 0 {
+		buf := make([]byte, len(p))
+		n, err := f.r.Read(buf)
+		if n == 0 {
+			return 0, err
+		}
+
+		for _, b := range buf[:n] {
+			switch b {
+			case 0x7f, 0x08:
+				f.pending = utf8.AppendRune(f.pending, key_backspace)
+			case 0x03: // ^C
+				f.pending = utf8.AppendRune(f.pending, key_interrupt)
+			default:
+				f.pending = append(f.pending, b)
+			}
+		}
+	}
+
+	n := copy(p, f.pending)
+	f.pending = f.pending[n:]
+	return n, nil
+}
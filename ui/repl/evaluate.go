@@ -0,0 +1,92 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandEvaluate(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	name := "tests"
+	if len(args) >= 1 {
+		name = args[0]
+	}
+
+	data, err := ctx.Dataset(name)
+	if err != nil {
+		return err
+	}
+	if data.Len() == 0 {
+		return ErrEmptyDataset
+	}
+
+	k := 3
+	if len(args) >= 2 {
+		k, err = strconv.Atoi(args[1])
+		if err != nil {
+			return ErrBadNumber(err)
+		}
+		if k < 1 {
+			return ErrBadTopK
+		}
+	}
+
+	eval := ctx.NeuralNetwork.Evaluate(data, k)
+
+	if eval.Task == nn.TaskRegression {
+		fmt.Fprintf(w,
+			"MSE: %f\nMAE: %f\nR²: %f\nCost: %f\n",
+			eval.MSE(), eval.MAE, eval.R2, eval.Cost,
+		)
+		return nil
+	}
+
+	labels := class_labels(ctx.NeuralNetwork)
+	if eval.Task == nn.TaskClassification {
+		fmt.Fprintf(w, "Confusion matrix (rows are labels, columns are predictions):\n\n%s\n", Heatmap(eval.Confusion, labels))
+	}
+
+	width := len("Class")
+	for _, label := range labels {
+		width = max(width, utf8.RuneCountInString(label))
+	}
+
+	fmt.Fprintf(w, "%s %10s %10s %10s %8s\n", pad_left("Class", width), "Precision", "Recall", "F1", "Support")
+	for class := range eval.Classes() {
+		fmt.Fprintf(w, "%s %10.4f %10.4f %10.4f %8d\n",
+			pad_left(labels[class], width), eval.Precision(class), eval.Recall(class), eval.F1(class), eval.Support(class),
+		)
+	}
+
+	precision, recall, f1 := eval.Macro()
+	fmt.Fprintf(w, "%s %10.4f %10.4f %10.4f %8d\n", pad_left("Macro", width), precision, recall, f1, eval.Total)
+
+	precision, recall, f1 = eval.Micro()
+	fmt.Fprintf(w, "%s %10.4f %10.4f %10.4f %8d\n", pad_left("Micro", width), precision, recall, f1, eval.Total)
+
+	if eval.Task == nn.TaskMultiLabel {
+		fmt.Fprintf(w,
+			"\nExact match: %.2f%%\nHamming loss: %f\nLog-loss: %f\nCost: %f\n",
+			100*eval.Accuracy(), eval.HammingLoss(), eval.LogLoss, eval.Cost,
+		)
+		return nil
+	}
+
+	fmt.Fprintf(w,
+		"\nAccuracy: %.2f%%\nTop-%d accuracy: %.2f%%\nLog-loss: %f\nCost: %f\n",
+		100*eval.Accuracy(), eval.K, 100*eval.TopKAccuracy(), eval.LogLoss, eval.Cost,
+	)
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package repl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// Dimensions of the SVG charts, in pixels. Every panel is svg_panel high,
+// with svg_margin around it.
+const (
+	svg_width  = 800
+	svg_panel  = 220
+	svg_margin = 56
+	svg_legend = 24
+)
+
+// svg_panels are the panels of the SVG charts, each plotting the given
+// metrics, see [screen_metrics], against a y axis of its own.
+var svg_panels = []struct {
+	title   string
+	metrics []string
+}{
+	{"Cost", []string{"training", "cost", "validation"}},
+	{"Accuracy", []string{"accuracy"}},
+	{"Learning rate", []string{"rate"}},
+}
+
+func CommandPlot(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if len(args) < 1 {
+		width, height, ok := term_size(w)
+		if !ok || width <= 0 {
+			width, height = 80, 24
+		}
+
+		fmt.Fprint(w, state.screen.plot(ctx, width, height-1))
+		return nil
+	}
+
+	if args[0] != "export" {
+		return ErrUnknownDirective(args[0])
+	}
+	if len(args) < 2 {
+		return ErrPlotMissingArgs
+	}
+	path := args[1]
+
+	if len(ctx.Evolution) == 0 {
+		return ErrEmptyHistory
+	}
+
+	var paths []string
+	switch filepath.Ext(path) {
+	case ".svg", ".csv":
+		paths = []string{path}
+	default:
+		paths = []string{path + ".svg", path + ".csv"}
+	}
+
+	for _, path := range paths {
+		if err := export_history(path, ctx.Evolution, state.focus); err != nil {
+			return fmt.Errorf("plot export: %w", err)
+		}
+		fmt.Fprintf(w, "Exported %d records onto %s.\n", len(ctx.Evolution), path)
+	}
+
+	return nil
+}
+
+func export_history(path string, records []nn.Record, title string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if filepath.Ext(path) == ".csv" {
+		return WriteCSV(f, records)
+	}
+	return WriteSVG(f, records, title)
+}
+
+// WriteCSV writes the records as CSV, with a header, leaving out the metrics
+// that were not measured.
+func WriteCSV(w io.Writer, records []nn.Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"cycle", "training_cost", "test_cost", "validation_cost", "accuracy", "learning_rate"})
+
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	for _, r := range records {
+		cw.Write([]string{
+			strconv.Itoa(r.Cycle),
+			format(measured(r.TrainingCost)),
+			format(r.Cost),
+			format(measured(r.ValidationCost)),
+			format(r.Accuracy),
+			format(measured(r.LearningRate)),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSVG writes a self-contained SVG chart of the records, with a panel for
+// the costs, the accuracy and the learning rate, those that were measured,
+// against the cycle.
+func WriteSVG(w io.Writer, records []nn.Record, title string) error {
+	type panel struct {
+		title  string
+		series []Series
+	}
+
+	var panels []panel
+	for _, p := range svg_panels {
+		var series []Series
+		for _, name := range p.metrics {
+			metric := screen_metrics[name]
+
+			values := make([]float64, len(records))
+			var found bool
+			for i, r := range records {
+				values[i] = metric.value(r)
+				found = found || is_finite(values[i])
+			}
+
+			if found {
+				series = append(series, Series{Name: metric.label, Values: values, Color: metric.color})
+			}
+		}
+
+		if len(series) > 0 {
+			panels = append(panels, panel{p.title, series})
+		}
+	}
+
+	x_lo, x_hi := float64(records[0].Cycle), float64(records[len(records)-1].Cycle)
+	if x_lo == x_hi {
+		x_lo, x_hi = x_lo-1, x_hi+1
+	}
+
+	height := svg_margin + len(panels)*(svg_panel+svg_margin+svg_legend)
+	left, right := float64(svg_margin+16), float64(svg_width-svg_margin/2)
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %[1]d %[2]d" font-family="sans-serif" font-size="12">`+"\n", svg_width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n", svg_margin/2, svg_margin/2, html.EscapeString(title))
+
+	x := func(v float64) float64 { return left + (v-x_lo)/(x_hi-x_lo)*(right-left) }
+
+	for i, p := range panels {
+		top := float64(svg_margin + i*(svg_panel+svg_margin+svg_legend))
+		bottom := top + svg_panel
+
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, s := range p.series {
+			for _, v := range s.Values {
+				if is_finite(v) {
+					lo, hi = min(lo, v), max(hi, v)
+				}
+			}
+		}
+		lo, hi = widen(lo, hi)
+
+		y_ticks := nice_ticks(lo, hi, 5)
+		lo, hi = min(lo, y_ticks[0]), max(hi, y_ticks[len(y_ticks)-1])
+
+		y := func(v float64) float64 { return bottom - (v-lo)/(hi-lo)*(bottom-top) }
+
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-weight="bold">%s</text>`+"\n", left, top-8, html.EscapeString(p.title))
+
+		for _, t := range y_ticks {
+			fmt.Fprintf(&b, `<line x1="%g" y1="%.2f" x2="%g" y2="%.2f" stroke="#e0e0e0"/>`+"\n", left, y(t), right, y(t))
+			fmt.Fprintf(&b, `<text x="%g" y="%.2f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", left-6, y(t), format_tick(t))
+		}
+		for _, t := range nice_ticks(x_lo, x_hi, 8) {
+			if t < x_lo || t > x_hi {
+				continue
+			}
+			fmt.Fprintf(&b, `<line x1="%.2f" y1="%g" x2="%.2f" y2="%g" stroke="#e0e0e0"/>`+"\n", x(t), top, x(t), bottom)
+			fmt.Fprintf(&b, `<text x="%.2f" y="%g" text-anchor="middle">%s</text>`+"\n", x(t), bottom+16, format_tick(t))
+		}
+
+		fmt.Fprintf(&b, `<polyline points="%g,%g %g,%g %g,%g" fill="none" stroke="black"/>`+"\n", left, top, left, bottom, right, bottom)
+		fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="end">cycle</text>`+"\n", right, bottom+32)
+
+		legend := left
+		for _, s := range p.series {
+			fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n", svg_points(records, s.Values, x, y), xterm_color(s.Color))
+
+			fmt.Fprintf(&b, `<rect x="%g" y="%g" width="16" height="4" fill="%s"/>`+"\n", legend, bottom+38, xterm_color(s.Color))
+			fmt.Fprintf(&b, `<text x="%g" y="%g" dominant-baseline="middle">%s</text>`+"\n", legend+22, bottom+40, html.EscapeString(s.Name))
+			legend += 40 + 7*float64(len(s.Name))
+		}
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svg_points returns the points of an SVG polyline through the finite values,
+// in the coordinates given by x and y.
+func svg_points(records []nn.Record, values []float64, x, y func(float64) float64) string {
+	var points []string
+	for i, v := range values {
+		if is_finite(v) {
+			points = append(points, fmt.Sprintf("%.2f,%.2f", x(float64(records[i].Cycle)), y(v)))
+		}
+	}
+
+	return strings.Join(points, " ")
+}
+
+// tick_epsilon is the width, relative to the magnitude of its ends, under
+// which a range is taken as a single value, and max_ticks, the most ticks
+// nice_ticks returns.
+const (
+	tick_epsilon = 1e-9
+	max_ticks    = 64
+)
+
+// widen returns the range as is, or, if it is too narrow to be told from a
+// single value, widened about it.
+func widen(lo, hi float64) (float64, float64) {
+	magnitude := max(math.Abs(lo), math.Abs(hi))
+	if hi-lo > tick_epsilon*magnitude {
+		return lo, hi
+	}
+
+	pad := max(0.5, 1e-3*magnitude)
+	return lo - pad, hi + pad
+}
+
+// nice_ticks returns about n round values, multiples of 1, 2 or 5 times a
+// power of ten, that span from lo to hi, both included. Narrow ranges are
+// widened first, see widen, and no more than max_ticks are returned.
+func nice_ticks(lo, hi float64, n int) []float64 {
+	lo, hi = widen(lo, hi)
+
+	raw := (hi - lo) / float64(max(n-1, 1))
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+
+	step := 10 * magnitude
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+
+	// steps that overflow or vanish against the ends give no ticks
+	start := math.Floor(lo/step) * step
+	if math.IsNaN(start) || math.IsInf(start, 0) || start+step == start {
+		return []float64{lo, hi}
+	}
+
+	var ticks []float64
+	for t := start; t < hi+step && len(ticks) < max_ticks; t += step {
+		ticks = append(ticks, math.Round(t/step)*step)
+	}
+
+	return ticks
+}
+
+func format_tick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// xterm_color returns the hex RGB of a color of the 256-color palette, only
+// those of the 6×6×6 cube and the grays are supported, others are black.
+func xterm_color(i int) string {
+	switch {
+	case i >= 16 && i < 232:
+		levels := [...]int{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[i/36], levels[i/6%6], levels[i%6])
+	case i >= 232 && i < 256:
+		gray := 8 + 10*(i-232)
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+
+	return "#000000"
+}
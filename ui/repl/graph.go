@@ -4,99 +4,309 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
-	_UpRight    = '╰'
-	_DownRight  = '╭'
+	_UpRight    = '└'
 	_Horizontal = '─'
 	_Vertical   = '│'
-	_UpLeft     = '╯'
-	_DownLeft   = '╮'
+	_TickLeft   = '┤'
+	_TickDown   = '┬'
 
-	_BrailleBase = '\u2800'
+	_BrailleBase = '⠀'
 )
 
-func Graph(points []float64, width, height int) (int, string) {
-	width, height = width-2, height-4
-	domain, image := 2*width, 4*height
+// _SeriesPalette are the colors of the series, on the 256-color palette, when
+// not given.
+var _SeriesPalette = [...]int{39, 208, 170, 40, 220, 196}
 
-	if len(points) > domain {
-		points = points[len(points)-domain:]
-	}
+// Series is a named sequence of values to be plotted in the given color, of
+// the 256-color palette, or one of [_SeriesPalette] if zero. NaN values are
+// left out.
+type Series struct {
+	Name   string
+	Values []float64
+	Color  int
+}
+
+// PlotOptions configure how series are plotted.
+type PlotOptions struct {
+	// Log makes the y axis logarithmic, non-positive values are left out.
+	Log bool
+
+	// Smooth is the window of the trailing moving average the series are
+	// smoothed with, none if less than two.
+	Smooth int
+
+	// Ticks is the number of labelled ticks on the y axis, 5 if zero.
+	Ticks int
+}
 
-	minp, maxp := math.Inf(1), math.Inf(-1)
-	for _, p := range points {
-		minp = min(minp, p)
-		maxp = max(maxp, p)
+// Plot plots the series with braille dots on a canvas of width by height
+// characters, axes, tick labels and a legend included. The series share the
+// x axis, whose i-th point is labelled by xs[i], or i if xs is nil, and only
+// their last points are plotted if there are more than there is room for.
+func Plot(series []Series, xs []int, width, height int, opts PlotOptions) string {
+	ticks := opts.Ticks
+	if ticks <= 0 {
+		ticks = 5
 	}
 
-	mat := make([]int, domain)
+	var length int
+	for _, s := range series {
+		length = max(length, len(s.Values))
+	}
 
-	x := domain - len(points)
-	for _, p := range points {
-		y := int(math.Round(float64(image+1) * (p - minp) / (maxp - minp)))
-		y = min(max(0, y), image-1)
+	// every series is smoothed, transformed, and aligned to the right
+	values := make([][]float64, len(series))
+	for i, s := range series {
+		v := smooth(s.Values, opts.Smooth)
+		if opts.Log {
+			for j := range v {
+				v[j] = math.Log10(v[j])
+			}
+		}
 
-		mat[x] = image - 1 - y
-		x++
+		values[i] = append(make([]float64, length-len(v)), v...)
+		for j := range length - len(v) {
+			values[i][j] = math.NaN()
+		}
 	}
-	for i := range domain - len(points) {
-		mat[i] = -1
+
+	label := func(v float64) string {
+		if opts.Log {
+			v = math.Pow(10, v)
+		}
+		return fmt.Sprintf("%.3g", v)
 	}
 
-	var b strings.Builder
-	b.Grow(3*(2*width+2*height+len(points)+4) + width*height - len(points) + width - 1)
+	legend := plot_legend(series, opts, width)
+
+	plot_height := height - 2 - strings.Count(legend, "\n")
+	if plot_height < 1 {
+		return ""
+	}
 
-	b.WriteRune(_DownRight)
-	for range width {
-		b.WriteRune(_Horizontal)
+	// the labelled rows, evenly spaced from the top to the bottom one
+	var rows []int
+	for k := range min(ticks, plot_height) {
+		if n := min(ticks, plot_height); n > 1 {
+			rows = append(rows, k*(plot_height-1)/(n-1))
+		} else {
+			rows = append(rows, 0)
+		}
 	}
-	b.WriteRune(_DownLeft)
 
-	for y := range height {
-		b.WriteRune(_Vertical)
-		for x := range width {
-			braille := _BrailleBase
+	// the range of the y axis only spans the points that fit, and how many
+	// fit depends on the width of the labels of the range, so the width is
+	// found again until it settles
+	var (
+		label_width, plot_width int
+		start, offset           int
+		lo, hi                  float64
+		tick_labels             map[int]string
+	)
+	for range 3 {
+		plot_width = width - label_width - 2
+		if plot_width < 1 {
+			return ""
+		}
+
+		start = max(length-2*plot_width, 0)
+		offset = 2*plot_width - (length - start)
 
-			if val := mat[x*2]; val >= 0 && y == val/4 {
-				rem := val % 4
-				if rem < 3 {
-					braille |= rune(1 << rem)
-				} else {
-					braille |= rune(1 << 6)
+		lo, hi = math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			for _, p := range v[start:] {
+				if is_finite(p) {
+					lo, hi = min(lo, p), max(hi, p)
 				}
 			}
+		}
+		if lo > hi {
+			lo, hi = 0, 1
+		}
+		if lo == hi {
+			lo, hi = lo-0.5, hi+0.5
+		}
 
-			if val := mat[x*2+1]; val >= 0 && y == val/4 {
-				rem := val % 4
-				if rem < 3 {
-					braille |= rune(1 << (rem + 3))
-				} else {
-					braille |= rune(1 << 7)
-				}
+		tick_labels = make(map[int]string, len(rows))
+		widest := 0
+		for _, row := range rows {
+			v := hi - float64(row)/float64(max(plot_height-1, 1))*(hi-lo)
+			tick_labels[row] = label(v)
+			widest = max(widest, utf8.RuneCountInString(tick_labels[row]))
+		}
+
+		if widest == label_width {
+			break
+		}
+		label_width = widest
+	}
+
+	image := 4 * plot_height
+
+	dots := make([][]rune, plot_height)
+	colors := make([][]int, plot_height)
+	for y := range plot_height {
+		dots[y] = make([]rune, plot_width)
+		colors[y] = make([]int, plot_width)
+	}
+
+	dot := func(x, y, color int) {
+		bit := [2][4]rune{{0, 1, 2, 6}, {3, 4, 5, 7}}[x%2][y%4]
+		dots[y/4][x/2] |= 1 << bit
+		colors[y/4][x/2] = color
+	}
+
+	for i, v := range values {
+		color := series[i].Color
+		if color == 0 {
+			color = _SeriesPalette[i%len(_SeriesPalette)]
+		}
+
+		prev := -1
+		for j, p := range v[start:] {
+			if !is_finite(p) {
+				prev = -1
+				continue
 			}
 
-			if braille == _BrailleBase {
+			y := image - 1 - int(math.Round(float64(image-1)*(p-lo)/(hi-lo)))
+			y = min(max(0, y), image-1)
+
+			x := offset + j
+			if prev < 0 {
+				prev = y
+			}
+
+			// joins the point to the previous one
+			for k := min(prev, y); k <= max(prev, y); k++ {
+				dot(x, k, color)
+			}
+			prev = y
+		}
+	}
+
+	var b strings.Builder
+
+	for y := range plot_height {
+		if text, in := tick_labels[y]; in {
+			fmt.Fprintf(&b, "%*s %c", label_width, text, _TickLeft)
+		} else {
+			fmt.Fprintf(&b, "%*s %c", label_width, "", _Vertical)
+		}
+
+		for x := range plot_width {
+			if dots[y][x] == 0 {
 				b.WriteByte(' ')
-			} else {
-				b.WriteRune(braille)
+				continue
 			}
+			fmt.Fprintf(&b, "\033[38;5;%dm%c\033[0m", colors[y][x], _BrailleBase|dots[y][x])
+		}
+		b.WriteByte('\n')
+	}
+
+	// the x axis is labelled at its ends and in its middle
+	axis := []rune(strings.Repeat(string(_Horizontal), plot_width))
+	labels := []rune(strings.Repeat(" ", plot_width))
+	for _, col := range []int{0, plot_width / 2, plot_width - 1} {
+		i := start + 2*col - offset
+		if i < start || i >= length {
+			continue
+		}
+
+		x := i
+		if xs != nil {
+			x = xs[i]
+		}
+
+		text := []rune(fmt.Sprint(x))
+		at := min(max(col-len(text)/2, 0), plot_width-len(text))
+		if at < 0 || strings.TrimSpace(string(labels[at:at+len(text)])) != "" || at > 0 && labels[at-1] != ' ' {
+			continue
+		}
+
+		axis[col] = _TickDown
+		copy(labels[at:], text)
+	}
+
+	fmt.Fprintf(&b, "%*s %c%s\n", label_width, "", _UpRight, string(axis))
+	fmt.Fprintf(&b, "%*s  %s\n", label_width, "", strings.TrimRight(string(labels), " "))
+
+	b.WriteString(legend)
+
+	return b.String()
+}
+
+// plot_legend returns the legend of the series, wrapped to width.
+func plot_legend(series []Series, opts PlotOptions, width int) string {
+	var entries []string
+	for i, s := range series {
+		color := s.Color
+		if color == 0 {
+			color = _SeriesPalette[i%len(_SeriesPalette)]
 		}
-		b.WriteRune(_Vertical)
+		entries = append(entries, fmt.Sprintf("\033[38;5;%dm━━\033[0m %s", color, s.Name))
+	}
+	if opts.Log {
+		entries = append(entries, "(log scale)")
 	}
+	if opts.Smooth > 1 {
+		entries = append(entries, fmt.Sprintf("(smoothed over %d)", opts.Smooth))
+	}
+
+	var b strings.Builder
 
-	b.WriteRune(_UpRight)
-	for range width {
-		b.WriteRune(_Horizontal)
+	var column int
+	for _, entry := range entries {
+		length := visible_len(entry)
+		if column > 0 && column+2+length > width {
+			b.WriteByte('\n')
+			column = 0
+		}
+		if column > 0 {
+			b.WriteString("  ")
+			column += 2
+		}
+
+		b.WriteString(entry)
+		column += length
 	}
-	b.WriteRune(_UpLeft)
+	b.WriteByte('\n')
 
-	cc := fmt.Sprintf("max: %f, min: %f\n", maxp, minp)
-	for range width + 2 - len(cc) {
-		b.WriteByte(' ')
+	return b.String()
+}
+
+// smooth returns the trailing moving average of values over window points,
+// skipping NaN values, or a copy of them if window is less than two.
+func smooth(values []float64, window int) []float64 {
+	smoothed := make([]float64, len(values))
+	if window < 2 {
+		copy(smoothed, values)
+		return smoothed
 	}
-	b.WriteString(cc)
 
-	return len(points), b.String()
+	for i := range values {
+		var sum float64
+		var n int
+		for _, v := range values[max(i-window+1, 0) : i+1] {
+			if !math.IsNaN(v) {
+				sum += v
+				n++
+			}
+		}
+
+		smoothed[i] = math.NaN()
+		if n > 0 && !math.IsNaN(values[i]) {
+			smoothed[i] = sum / float64(n)
+		}
+	}
+
+	return smoothed
+}
+
+func is_finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
 }
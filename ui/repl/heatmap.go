@@ -0,0 +1,69 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// _HeatPalette is a black-red-yellow-white ramp on the 256-color palette.
+var _HeatPalette = [...]int{16, 52, 88, 124, 160, 196, 202, 208, 214, 220, 226, 227, 228, 229, 230, 231}
+
+// Heatmap renders a matrix of counts, such as a confusion matrix, as a table
+// whose cells are colored according to their share of the row total. Rows and
+// columns are labeled by the given labels, one per row.
+func Heatmap(matrix [][]int, labels []string) string {
+	var width int
+	for _, row := range matrix {
+		for _, n := range row {
+			width = max(width, len(fmt.Sprint(n)))
+		}
+	}
+	for _, label := range labels {
+		width = max(width, utf8.RuneCountInString(label))
+	}
+	width += 2
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%*s ", width, "")
+	for _, label := range labels {
+		b.WriteString(pad_left(label, width))
+	}
+	b.WriteByte('\n')
+
+	for i, row := range matrix {
+		var total int
+		for _, n := range row {
+			total += n
+		}
+
+		b.WriteString(pad_left(labels[i], width) + " ")
+		for _, n := range row {
+			var level int
+			if total > 0 {
+				level = n * (len(_HeatPalette) - 1) / total
+				if n > 0 {
+					level = max(level, 1)
+				}
+			}
+
+			fg := 231
+			if level >= len(_HeatPalette)/2 {
+				fg = 16
+			}
+
+			fmt.Fprintf(&b, "\033[38;5;%d;48;5;%dm%*d\033[0m", fg, _HeatPalette[level], width, n)
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+func pad_left(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
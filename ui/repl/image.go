@@ -0,0 +1,182 @@
+package repl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ImageMode is the way a grayscale image is rendered onto the terminal.
+type ImageMode int
+
+const (
+	// ImageBlocks renders two vertical pixels per cell with the shades
+	// ' ', '░', '▒', '▓' and '█'.
+	ImageBlocks ImageMode = iota
+
+	// ImageBraille renders 2x4 pixels per cell as braille dots, dithered.
+	ImageBraille
+
+	// ImageASCII renders two vertical pixels per cell with the characters
+	// of a plain ASCII ramp.
+	ImageASCII
+
+	// ImageANSI renders two vertical pixels per cell as an upper half
+	// block, colored with the grayscale ramp of the 256-color palette.
+	ImageANSI
+)
+
+var _ImageModes = map[string]ImageMode{
+	"blocks":  ImageBlocks,
+	"braille": ImageBraille,
+	"ascii":   ImageASCII,
+	"ansi":    ImageANSI,
+}
+
+// ParseImageMode returns the image mode with the given name, one of "blocks",
+// "braille", "ascii" or "ansi".
+func ParseImageMode(name string) (ImageMode, error) {
+	mode, in := _ImageModes[name]
+	if !in {
+		return 0, ErrUnknownDirective(name)
+	}
+
+	return mode, nil
+}
+
+var (
+	_Shades = [...]rune{' ', '░', '▒', '▓', '█'}
+	_ASCII  = " .:-=+*#%@"
+)
+
+// _Bayer is a 4x4 ordered dithering matrix, normalized to (0, 1).
+var _Bayer = [4][4]float64{
+	{0.5 / 16, 8.5 / 16, 2.5 / 16, 10.5 / 16},
+	{12.5 / 16, 4.5 / 16, 14.5 / 16, 6.5 / 16},
+	{3.5 / 16, 11.5 / 16, 1.5 / 16, 9.5 / 16},
+	{15.5 / 16, 7.5 / 16, 13.5 / 16, 5.5 / 16},
+}
+
+// Image renders a grayscale image with values in [0, 1], laid out row by row
+// with the given width, into lines of text. All lines have the same number of
+// runes.
+func Image(values []float64, width int, mode ImageMode) []string {
+	height := len(values) / width
+
+	at := func(x, y int) float64 {
+		if x >= width || y >= height {
+			return 0
+		}
+		return min(max(values[y*width+x], 0), 1)
+	}
+
+	var lines []string
+	switch mode {
+	case ImageBraille:
+		for y := 0; y < height; y += 4 {
+			var b strings.Builder
+			for x := 0; x < width; x += 2 {
+				braille := _BrailleBase
+				for dy := range 4 {
+					for dx := range 2 {
+						if at(x+dx, y+dy) > _Bayer[(y+dy)%4][(x+dx)%4] {
+							braille |= braille_dot(dx, dy)
+						}
+					}
+				}
+				b.WriteRune(braille)
+			}
+			lines = append(lines, b.String())
+		}
+
+	case ImageASCII:
+		for y := 0; y < height; y += 2 {
+			var b strings.Builder
+			for x := range width {
+				v := (at(x, y) + at(x, y+1)) / 2
+				b.WriteByte(_ASCII[int(v*float64(len(_ASCII)-1)+.5)])
+			}
+			lines = append(lines, b.String())
+		}
+
+	case ImageANSI:
+		for y := 0; y < height; y += 2 {
+			var b strings.Builder
+			for x := range width {
+				fmt.Fprintf(&b, "\033[38;5;%d;48;5;%dm▀", gray(at(x, y)), gray(at(x, y+1)))
+			}
+			b.WriteString("\033[0m")
+			lines = append(lines, b.String())
+		}
+
+	default:
+		for y := 0; y < height; y += 2 {
+			var b strings.Builder
+			for x := range width {
+				v := (at(x, y) + at(x, y+1)) / 2
+				b.WriteRune(_Shades[int(v*float64(len(_Shades)-1)+.5)])
+			}
+			lines = append(lines, b.String())
+		}
+	}
+
+	return lines
+}
+
+// gray returns the color of the 256-color palette closest to the given
+// intensity, black being 16 and white being 231, with the grayscale ramp
+// 232-255 in between.
+func gray(v float64) int {
+	level := int(v*25 + .5)
+	switch level {
+	case 0:
+		return 16
+	case 25:
+		return 231
+	}
+	return 231 + level
+}
+
+// braille_dot returns the bit of the braille dot at column x and row y of a
+// cell.
+func braille_dot(x, y int) rune {
+	if y == 3 {
+		return rune(1 << (6 + x))
+	}
+	return rune(1 << (y + 3*x))
+}
+
+// Columns lays blocks of lines side by side, separated by gap spaces. Every
+// line of a block must have the same width in runes.
+func Columns(blocks [][]string, gap int) string {
+	var height int
+	for _, block := range blocks {
+		height = max(height, len(block))
+	}
+
+	var b strings.Builder
+	for y := range height {
+		for i, block := range blocks {
+			if i > 0 {
+				b.WriteString(strings.Repeat(" ", gap))
+			}
+
+			if y < len(block) {
+				b.WriteString(block[y])
+			} else if len(block) > 0 {
+				b.WriteString(strings.Repeat(" ", visible_len(block[0])))
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+var reEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// visible_len returns the number of runes of a line, not counting the ANSI
+// color escape sequences.
+func visible_len(line string) int {
+	return len([]rune(reEscape.ReplaceAllString(line, "")))
+}
@@ -0,0 +1,114 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandInfo(state *State, w io.Writer, _ io.Reader, _ ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	ctx.Sync()
+	Info(w, ctx.NeuralNetwork)
+	return nil
+}
+
+func CommandNote(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrNoteMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	meta := ctx.NeuralNetwork.Metadata()
+	if meta.Notes != "" {
+		meta.Notes += "\n"
+	}
+	meta.Notes += strings.Join(args, " ")
+
+	ctx.NeuralNetwork.SetMetadata(meta)
+	ctx.Unsaved = true
+	return nil
+}
+
+// Info writes a description of the network and its metadata.
+func Info(w io.Writer, network *nn.NeuralNetwork) {
+	meta := network.Metadata()
+	dims := network.Dims()
+
+	var parameters int
+	for i := range len(dims) - 1 {
+		parameters += dims[i+1]*dims[i] + dims[i+1]
+	}
+
+	fmt.Fprintf(w, "Dimensions: %s\n", strings.Trim(fmt.Sprint(dims), "[]"))
+	fmt.Fprintf(w, "Parameters: %d\n", parameters)
+	fmt.Fprintf(w, "Task: %s\n", network.Task())
+	if names := network.Classes().Names; names != nil {
+		fmt.Fprintf(w, "Classes: %s\n", strings.Join(names, ", "))
+	}
+
+	if !meta.Created.IsZero() {
+		fmt.Fprintf(w, "Created: %s\n", meta.Created.Format("2006-01-02 15:04:05 -0700"))
+	}
+	fmt.Fprintf(w, "Cycles: %d\n", meta.Cycles)
+
+	hp := meta.Hyperparameters
+	fmt.Fprintf(w, "\nLearning rate: %g\n", hp.LearningRate)
+	if hp.BatchSize > 0 {
+		fmt.Fprintf(w, "Batch size: %d\n", hp.BatchSize)
+	}
+	for i, t := range hp.Augmentation {
+		fmt.Fprintf(w, "Augmentation %d: %s\n", i, t)
+	}
+
+	if n := len(meta.History); n > 0 {
+		first, last := meta.History[0], meta.History[n-1]
+
+		best := first
+		for _, record := range meta.History {
+			if record.Cost < best.Cost {
+				best = record
+			}
+		}
+
+		fmt.Fprintf(w, "\nHistory: %d records, from cycle %d to %d\n", n, first.Cycle, last.Cycle)
+		for _, r := range []struct {
+			name   string
+			record nn.Record
+		}{{"First", first}, {"Best", best}, {"Last", last}} {
+			if network.Task() == nn.TaskRegression {
+				fmt.Fprintf(w, "\t%-5s cycle %d, cost %f\n", r.name, r.record.Cycle, r.record.Cost)
+			} else {
+				fmt.Fprintf(w, "\t%-5s cycle %d, cost %f, accuracy %.2f%%\n", r.name, r.record.Cycle, r.record.Cost, 100*r.record.Accuracy)
+			}
+		}
+	}
+
+	if len(meta.Datasets) > 0 {
+		fmt.Fprint(w, "\nDatasets:\n")
+		for _, d := range meta.Datasets {
+			path := strings.Join(append([]string{d.Path}, d.Extra...), " ")
+			fmt.Fprintf(w, "\t%-10s %s, %d samples, sha256 %.16s\n", d.Set, path, d.Count, d.Hash)
+		}
+	}
+
+	if meta.Notes != "" {
+		fmt.Fprintf(w, "\nNotes:\n\t%s\n", strings.ReplaceAll(meta.Notes, "\n", "\n\t"))
+	}
+}
@@ -0,0 +1,166 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alan-b-lima/nn-digits/internal/dataset"
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+func CommandShow(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrShowMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	data, err := ctx.Dataset(args[0])
+	if err != nil {
+		return err
+	}
+
+	index, err := strconv.Atoi(args[1])
+	if err != nil {
+		return ErrBadNumber(err)
+	}
+	if index < 0 || data.Len() <= index {
+		return ErrOutOfRange(index, data.Len())
+	}
+
+	mode := ImageBlocks
+	if len(args) >= 3 {
+		mode, err = ParseImageMode(args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	sample := data.Get(index)
+	values := sample.Values.Data()
+	side := image_side(len(values))
+
+	label := ctx.NeuralNetwork.Classes().Name(nn.IndexOfMax(sample.Label.Data()))
+	fmt.Fprintf(w, "Sample %d of %s, label %s:\n\n", index, args[0], label)
+	for _, line := range Image(values, side, mode) {
+		fmt.Fprintln(w, line)
+	}
+
+	return nil
+}
+
+func CommandInspect(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrInspectMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	data, err := ctx.Dataset(args[0])
+	if err != nil {
+		return err
+	}
+	if data.Len() == 0 {
+		return ErrEmptyDataset
+	}
+
+	directive := "summary"
+	if len(args) >= 2 {
+		directive = args[1]
+	}
+
+	mode := ImageBlocks
+	if len(args) >= 3 {
+		mode, err = ParseImageMode(args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	summary := dataset.Summarize(data)
+
+	switch directive {
+	case "summary":
+		fmt.Fprintf(w,
+			"Count: %d\nFeatures: %d\nLabels: %d\nValue range: [%g, %g]\nDuplicates: %d\n\n",
+			summary.Count, len(summary.Mean), len(summary.Labels), summary.Min, summary.Max, summary.Duplicates,
+		)
+		io.WriteString(w, Histogram(summary.Labels, class_labels(ctx.NeuralNetwork), 40))
+
+	case "labels":
+		io.WriteString(w, Histogram(summary.Labels, class_labels(ctx.NeuralNetwork), 40))
+
+	case "mean", "variance":
+		values := summary.Mean
+		if directive == "variance" {
+			values = summary.Variance
+		}
+
+		// stretches the image so that its largest value is white
+		peak := slices.Max(values)
+		image := make([]float64, len(values))
+		for i, v := range values {
+			if peak > 0 {
+				image[i] = v / peak
+			}
+		}
+
+		fmt.Fprintf(w, "Per-pixel %s, ranging over [%g, %g]:\n\n", directive, slices.Min(values), peak)
+		for _, line := range Image(image, image_side(len(image)), mode) {
+			fmt.Fprintln(w, line)
+		}
+
+	default:
+		return ErrUnknownDirective(directive)
+	}
+
+	return nil
+}
+
+// Histogram renders counts as horizontal bars, the largest one being width
+// cells long, labeled by the given labels, one per count.
+func Histogram(counts []int, labels []string, width int) string {
+	var peak, total int
+	for _, n := range counts {
+		peak = max(peak, n)
+		total += n
+	}
+
+	label_width := 3
+	for _, label := range labels {
+		label_width = max(label_width, utf8.RuneCountInString(label))
+	}
+
+	var b strings.Builder
+	for i, n := range counts {
+		var bar int
+		if peak > 0 {
+			bar = n * width / peak
+		}
+
+		var share float64
+		if total > 0 {
+			share = 100 * float64(n) / float64(total)
+		}
+
+		fmt.Fprintf(&b, "%s %s%s %d (%.1f%%)\n", pad_left(labels[i], label_width), strings.Repeat("█", bar), strings.Repeat(" ", width-bar), n, share)
+	}
+
+	return b.String()
+}
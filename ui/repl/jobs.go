@@ -0,0 +1,395 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+type job_state int
+
+const (
+	job_running job_state = iota
+	job_paused
+	job_done
+	job_killed
+	job_diverged
+)
+
+func (s job_state) String() string {
+	switch s {
+	case job_running:
+		return "running"
+	case job_paused:
+		return "paused"
+	case job_done:
+		return "done"
+	case job_killed:
+		return "killed"
+	case job_diverged:
+		return "diverged"
+	}
+
+	return fmt.Sprintf("job_state(%d)", int(s))
+}
+
+// job is a train or cycle directive running in the background. It locks its
+// context for every step, so that directives may use the context in between.
+type job struct {
+	id      int
+	name    string
+	ctx     *Context
+	command string
+
+	// total is the number of steps of train jobs, cycle jobs run until
+	// killed.
+	total int
+
+	mu       sync.Mutex
+	cond     sync.Cond
+	state    job_state
+	progress int
+	last     nn.Record
+
+	// updates is signaled after every step, without blocking, and done is
+	// closed once the job finishes.
+	updates chan struct{}
+	done    chan struct{}
+}
+
+func CommandBg(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrBgMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	// jobs on the same context would train it at once
+	if state.busy(state.focus) {
+		return ErrContextBusy
+	}
+
+	size, iterations, err := parse_batch(args[1:])
+	if err != nil {
+		return err
+	}
+	if iterations < 1 {
+		return nil
+	}
+
+	j := job{
+		name:    state.focus,
+		ctx:     ctx,
+		command: strings.Join(args, " "),
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	j.cond.L = &j.mu
+
+	var t *nn.Trainer
+
+	var step func() nn.Record
+	switch args[0] {
+	case "train":
+		j.total = iterations
+		done := 0
+		step = func() nn.Record {
+			ctx.BatchSize = size
+			ctx.Cycle++
+			learn_batch(ctx, t, size)
+
+			// as in the foreground, the log gets a record once it is over
+			done++
+			if done == iterations && ctx.log != nil {
+				return measure(ctx, t)
+			}
+			return nn.Record{Cycle: ctx.Cycle}
+		}
+
+	case "cycle":
+		step = func() nn.Record {
+			ctx.BatchSize = size
+			ctx.Cycle++
+			for range iterations {
+				if t.Stopped() {
+					break
+				}
+				learn_batch(ctx, t, size)
+			}
+			if t.Stopped() {
+				return nn.Record{Cycle: ctx.Cycle}
+			}
+			return measure(ctx, t)
+		}
+
+	default:
+		return ErrUnknownDirective(args[0])
+	}
+
+	// the checkpoint is taken before the job may change the weights
+	ctx.mu.Lock()
+	ctx.checkpoint("bg", args...)
+	t = ctx.trainer(nn.Hooks{Divergence: func(t *nn.Trainer, _ nn.Divergence) {
+		j.diverge()
+		t.Stop()
+	}})
+	ctx.mu.Unlock()
+
+	state.last_job++
+	j.id = state.last_job
+	state.jobs[j.id] = &j
+
+	go j.run(step)
+
+	fmt.Fprintf(w, "[%d] %s\n", j.id, j.command)
+	return nil
+}
+
+func CommandJobs(state *State, w io.Writer, _ io.Reader, _ ...string) error {
+	for _, id := range slices.Sorted(maps.Keys(state.jobs)) {
+		j := state.jobs[id]
+		fmt.Fprintln(w, j)
+
+		if j.finished() {
+			delete(state.jobs, id)
+		}
+	}
+
+	return nil
+}
+
+func CommandFg(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	j, err := state.job("fg", args...)
+	if err != nil {
+		return err
+	}
+
+	j.resume()
+
+	if j.total == 0 {
+		io.WriteString(w, "\033[?1049h")
+		defer io.WriteString(w, "\033[?1049l")
+	}
+
+	for {
+		select {
+		case <-j.updates:
+			if j.total > 0 {
+				if state.interactive {
+					j.mu.Lock()
+					fmt.Fprintf(w, "\r%d/%d", j.progress, j.total)
+					j.mu.Unlock()
+				}
+				continue
+			}
+
+			j.mu.Lock()
+			record := j.last
+			j.mu.Unlock()
+
+			j.ctx.mu.Lock()
+			render_screen(w, j.ctx, record, state.screen)
+			j.ctx.mu.Unlock()
+
+		case <-state.signals:
+			j.kill()
+
+		case <-j.done:
+			if j.total > 0 && state.interactive {
+				fmt.Fprintln(w)
+			}
+			delete(state.jobs, j.id)
+			return nil
+		}
+	}
+}
+
+func CommandPause(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	j, err := state.job("pause", args...)
+	if err != nil {
+		return err
+	}
+
+	j.pause()
+	return nil
+}
+
+func CommandResume(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	j, err := state.job("resume", args...)
+	if err != nil {
+		return err
+	}
+
+	j.resume()
+	return nil
+}
+
+func CommandKill(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	j, err := state.job("kill", args...)
+	if err != nil {
+		return err
+	}
+
+	j.kill()
+	return nil
+}
+
+// job returns the unfinished job whose id is the first argument, with or
+// without a leading %.
+func (s *State) job(directive string, args ...string) (*job, error) {
+	if len(args) < 1 {
+		return nil, ErrJobMissingArgs(directive)
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "%"))
+	if err != nil {
+		return nil, ErrBadNumber(err)
+	}
+
+	j, in := s.jobs[id]
+	if !in {
+		return nil, ErrJobNotFound
+	}
+	if j.finished() {
+		return nil, ErrJobFinished
+	}
+
+	return j, nil
+}
+
+// busy tells whether the context with the given name, or any if the name is
+// empty, has unfinished jobs.
+func (s *State) busy(name string) bool {
+	for _, j := range s.jobs {
+		if (name == "" || j.name == name) && !j.finished() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// report writes the jobs that finished since they were last listed, and
+// forgets them.
+func (s *State) report(w io.Writer) {
+	for _, id := range slices.Sorted(maps.Keys(s.jobs)) {
+		if j := s.jobs[id]; j.finished() {
+			fmt.Fprintln(w, j)
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// run runs step, with the context locked, until the job is done or killed,
+// waiting while it is paused. The record step returns is kept as the last.
+func (j *job) run(step func() nn.Record) {
+	defer close(j.done)
+
+	for j.total == 0 || j.progress < j.total {
+		if !j.wait() {
+			return
+		}
+
+		j.ctx.mu.Lock()
+		record := step()
+		j.ctx.mu.Unlock()
+
+		j.mu.Lock()
+		j.progress++
+		j.last = record
+		j.mu.Unlock()
+
+		select {
+		case j.updates <- struct{}{}:
+		default:
+		}
+	}
+
+	j.mu.Lock()
+	j.state = job_done
+	j.mu.Unlock()
+}
+
+// wait blocks while the job is paused, and tells whether it should go on.
+func (j *job) wait() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for j.state == job_paused {
+		j.cond.Wait()
+	}
+
+	return j.state == job_running
+}
+
+func (j *job) pause() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state == job_running {
+		j.state = job_paused
+	}
+}
+
+func (j *job) resume() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state == job_paused {
+		j.state = job_running
+		j.cond.Broadcast()
+	}
+}
+
+// diverge stops the job, as its training diverged, once its current step
+// finishes.
+func (j *job) diverge() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state == job_running {
+		j.state = job_diverged
+	}
+}
+
+// kill stops the job and waits for its current step to finish.
+func (j *job) kill() {
+	j.mu.Lock()
+	if j.state == job_running || j.state == job_paused {
+		j.state = job_killed
+		j.cond.Broadcast()
+	}
+	j.mu.Unlock()
+
+	<-j.done
+}
+
+func (j *job) finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (j *job) String() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	progress := fmt.Sprintf("cycle %d", j.last.Cycle)
+	if j.total > 0 {
+		progress = fmt.Sprintf("%d/%d", j.progress, j.total)
+	}
+
+	return fmt.Sprintf("[%d] %-7s %s: %s, %s", j.id, j.state, j.name, j.command, progress)
+}
@@ -0,0 +1,152 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/alan-b-lima/nn-digits/internal/metrics"
+	"github.com/alan-b-lima/nn-digits/internal/tensorboard"
+)
+
+func CommandLog(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	if len(args) < 1 {
+		ctx.mu.Lock()
+		defer ctx.mu.Unlock()
+
+		if ctx.log == nil {
+			fmt.Fprintln(w, "Not logging.")
+		} else {
+			fmt.Fprintf(w, "Logging onto %s.\n", ctx.log_path)
+		}
+		return nil
+	}
+
+	if state.busy(state.focus) {
+		return ErrContextBusy
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if err := ctx.close_log(); err != nil {
+		fmt.Fprintf(w, "log: %v\n", err)
+	}
+	if args[0] == "off" {
+		return nil
+	}
+
+	logger, err := metrics.Create(args[0], state.focus)
+	if err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+
+	ctx.log, ctx.log_path = logger, args[0]
+	return nil
+}
+
+// close_log stops logging the metrics of the context, if it does, and
+// returns the first error writing them, if any.
+func (ctx *Context) close_log() error {
+	if ctx.log == nil {
+		return nil
+	}
+
+	err := ctx.log.Close()
+	ctx.log, ctx.log_path = nil, ""
+	return err
+}
+
+// board_every is how many cycles apart the histograms of the weights are
+// written onto the event files at least, see [tensorboard.Logger].
+const board_every = 100
+
+func CommandTensorBoard(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	if len(args) < 1 {
+		ctx.mu.Lock()
+		defer ctx.mu.Unlock()
+
+		if ctx.board == nil {
+			fmt.Fprintln(w, "Not writing events.")
+		} else {
+			fmt.Fprintf(w, "Writing events onto %s.\n", ctx.board_dir)
+		}
+		return nil
+	}
+
+	if state.busy(state.focus) {
+		return ErrContextBusy
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if err := ctx.close_board(); err != nil {
+		fmt.Fprintf(w, "tensorboard: %v\n", err)
+	}
+	if args[0] == "off" {
+		return nil
+	}
+
+	dir := filepath.Join(args[0], state.focus)
+
+	writer, err := tensorboard.Create(dir)
+	if err != nil {
+		return fmt.Errorf("tensorboard: %w", err)
+	}
+
+	ctx.board, ctx.board_dir = tensorboard.NewLogger(writer), dir
+	ctx.board.Every = board_every
+	return nil
+}
+
+// close_board stops writing the events of the context, if it does, and
+// returns the first error writing them, if any.
+func (ctx *Context) close_board() error {
+	if ctx.board == nil {
+		return nil
+	}
+
+	err := ctx.board.Close()
+	ctx.board, ctx.board_dir = nil, ""
+	return err
+}
+
+// put makes ctx the context with the given name, the one it replaces, if any,
+// is closed, see [Context.close].
+func (s *State) put(name string, ctx *Context) {
+	if old, in := s.ctxs[name]; in && old != ctx {
+		old.close()
+	}
+
+	s.ctxs[name] = ctx
+}
+
+// close closes every context, see [Context.close].
+func (s *State) close() {
+	for _, ctx := range s.ctxs {
+		ctx.close()
+	}
+}
+
+// close stops logging the metrics and writing the events of the context, and
+// releases the files its datasets are read from. Errors are ignored, as there
+// is no one left to report them to.
+func (ctx *Context) close() {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	ctx.close_log()
+	ctx.close_board()
+	ctx.close_files()
+}
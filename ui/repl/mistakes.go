@@ -0,0 +1,148 @@
+package repl
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+type mistake struct {
+	Index      int
+	Label      int
+	Predicted  int
+	Confidence float64
+}
+
+func CommandMistakes(state *State, w io.Writer, r io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.Tests.Len() == 0 {
+		return ErrEmptyDataset
+	}
+	if task := ctx.NeuralNetwork.Task(); task != nn.TaskClassification {
+		return ErrTaskUnsupported(task)
+	}
+
+	page := 8
+	if len(args) >= 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return ErrBadNumber(err)
+		}
+		page = max(n, 1)
+	}
+
+	mistakes := find_mistakes(ctx.NeuralNetwork, ctx.Tests)
+	if len(mistakes) == 0 {
+		fmt.Fprintln(w, "There are no misclassified samples.")
+		return nil
+	}
+
+	slices.SortStableFunc(mistakes, func(a, b mistake) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+
+	side := image_side(ctx.Tests.Get(0).Values.Size())
+	width, _, ok := term_size(w)
+	if !ok {
+		width = 80
+	}
+
+	classes := ctx.NeuralNetwork.Classes()
+	pages := (len(mistakes) + page - 1) / page
+	fmt.Fprintf(w, "%d misclassified out of %d test samples.\n\n", len(mistakes), ctx.Tests.Len())
+
+	for p := range pages {
+		chunk := mistakes[p*page : min((p+1)*page, len(mistakes))]
+
+		var row [][]string
+		var used int
+		for _, m := range chunk {
+			block := Image(ctx.Tests.Get(m.Index).Values.Data(), side, ImageBlocks)
+			block = append(block,
+				pad(fmt.Sprintf("#%d", m.Index), side),
+				pad(fmt.Sprintf("%s as %s", classes.Name(m.Label), classes.Name(m.Predicted)), side),
+				pad(fmt.Sprintf("%.1f%%", 100*m.Confidence), side),
+			)
+
+			if used > 0 && used+side+2 > width {
+				io.WriteString(w, Columns(row, 2)+"\n")
+				row, used = nil, 0
+			}
+
+			row = append(row, block)
+			used += side + 2
+		}
+		io.WriteString(w, Columns(row, 2))
+
+		if p == pages-1 {
+			break
+		}
+		if !state.interactive {
+			fmt.Fprintln(w)
+			continue
+		}
+
+		fmt.Fprintf(w, "-- page %d/%d, [enter] for more or q to quit -- ", p+1, pages)
+		line, err := read_line(r)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "q" {
+			break
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+func find_mistakes(network *nn.NeuralNetwork, dataset nn.Dataset) []mistake {
+	var mistakes []mistake
+	for i, sample := range nn.All(dataset) {
+		output := network.FeedForward(sample.Values).Data()
+
+		predicted := nn.IndexOfMax(output)
+		label := nn.IndexOfMax(sample.Label.Data())
+
+		if predicted != label {
+			mistakes = append(mistakes, mistake{
+				Index:      i,
+				Label:      label,
+				Predicted:  predicted,
+				Confidence: output[predicted],
+			})
+		}
+	}
+
+	return mistakes
+}
+
+// image_side returns the side of a square image with the given number of
+// pixels, or the number of pixels itself if it is not a perfect square.
+func image_side(pixels int) int {
+	for side := 1; side*side <= pixels; side++ {
+		if side*side == pixels {
+			return side
+		}
+	}
+
+	return pixels
+}
+
+func pad(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:width])
+}
@@ -2,10 +2,10 @@ package repl
 
 import (
 	"bufio"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/rand/v2"
 	"os"
 	"os/signal"
@@ -13,26 +13,60 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
+	"github.com/alan-b-lima/nn-digits/internal/augment"
 	"github.com/alan-b-lima/nn-digits/internal/dataset"
+	"github.com/alan-b-lima/nn-digits/internal/metrics"
 	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/internal/tensorboard"
 
 	"golang.org/x/term"
 )
 
 type Directive func(*State, io.Writer, io.Reader, ...string) error
 
+// Context is a model along with its datasets and training state. Its fields
+// are guarded by its mutex, as background jobs train it concurrently with the
+// directives.
 type Context struct {
+	mu sync.Mutex
+
 	NeuralNetwork *nn.NeuralNetwork
 
-	Training []nn.Sample
-	Tests    []nn.Sample
+	Training   nn.Dataset
+	Tests      nn.Dataset
+	Validation nn.Dataset
 
 	LearningRate float64
+	BatchSize    int
+	Augmentation augment.Pipeline
 
 	Cycle     int
-	Evolution []float64
+	Evolution []nn.Record
+
+	// Sources are the files the datasets were loaded from.
+	Sources []nn.DatasetInfo
+
+	// Snapshots are the named snapshots, in the order they were taken, and
+	// undo, the automatic ones, the most recent last.
+	Snapshots []Snapshot
+	undo      []Snapshot
+
+	// log, if set, logs the metrics of every evaluation onto log_path, see
+	// [CommandLog].
+	log      *metrics.Logger
+	log_path string
+
+	// board, if set, writes the events of the training onto an event file
+	// in board_dir, see [CommandTensorBoard].
+	board     *tensorboard.Logger
+	board_dir string
+
+	// files are the files the datasets are read from, see [Context.hold].
+	files []*shared_file
 
 	Unsaved bool
 }
@@ -41,12 +75,78 @@ type State struct {
 	ctxs  map[string]*Context
 	focus string
 
+	// vars are the variables substituted into directives, see [CommandSet].
+	vars map[string]string
+
+	// interactive tells whether there is someone to prompt, errexit, whether
+	// scripts abort on the first error, and depth, how deeply scripts are
+	// being sourced.
+	interactive bool
+	errexit     bool
+	depth       int
+
+	// screen is how the history is plotted on the screen of cycle.
+	screen screen_settings
+
+	// jobs are the background jobs, by id, until they are reported
+	// finished, and last_job is the id of the last one started.
+	jobs     map[int]*job
+	last_job int
+
 	signals <-chan os.Signal
 }
 
+// NewContext returns a context for the given network with empty datasets. The
+// training state is picked up from where the metadata of the network left it.
+func NewContext(network *nn.NeuralNetwork) *Context {
+	meta := network.Metadata()
+
+	ctx := Context{
+		NeuralNetwork: network,
+		Training:      nn.Samples{},
+		Tests:         nn.Samples{},
+		Validation:    nn.Samples{},
+		LearningRate:  meta.Hyperparameters.LearningRate,
+		BatchSize:     meta.Hyperparameters.BatchSize,
+		Cycle:         meta.Cycles,
+		Evolution:     meta.History,
+	}
+
+	for _, t := range meta.Hyperparameters.Augmentation {
+		if transform, err := parse_transform(unlabel_params(t)...); err == nil {
+			ctx.Augmentation = append(ctx.Augmentation, transform)
+		}
+	}
+
+	return &ctx
+}
+
+// Sync writes the training state of the context onto the metadata of its
+// network.
+func (ctx *Context) Sync() {
+	meta := ctx.NeuralNetwork.Metadata()
+
+	meta.Cycles = ctx.Cycle
+	meta.History = ctx.Evolution
+	meta.Hyperparameters = nn.Hyperparameters{
+		LearningRate: ctx.LearningRate,
+		BatchSize:    ctx.BatchSize,
+	}
+	for _, t := range ctx.Augmentation {
+		meta.Hyperparameters.Augmentation = append(meta.Hyperparameters.Augmentation, t.String())
+	}
+
+	// keeps the datasets of a loaded model until others are loaded
+	if len(ctx.Sources) > 0 {
+		meta.Datasets = ctx.Sources
+	}
+
+	ctx.NeuralNetwork.SetMetadata(meta)
+}
+
 func (s *State) Unsaved() bool {
 	for _, ctx := range s.ctxs {
-		if ctx.Unsaved {
+		if ctx.unsaved() {
 			return true
 		}
 	}
@@ -54,90 +154,306 @@ func (s *State) Unsaved() bool {
 	return false
 }
 
+// unsaved tells whether the context has unsaved changes, locking it.
+func (ctx *Context) unsaved() bool {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	return ctx.Unsaved
+}
+
 func (s *State) Focused() *Context {
 	return s.ctxs[s.focus]
 }
 
+// Dataset returns the dataset of the context with the given name, one of
+// "training", "tests" or "validation".
+func (ctx *Context) Dataset(name string) (nn.Dataset, error) {
+	switch name {
+	case "training":
+		return ctx.Training, nil
+	case "tests":
+		return ctx.Tests, nil
+	case "validation":
+		return ctx.Validation, nil
+	}
+
+	return nil, ErrUnknownSet(name)
+}
+
+// Load loads the dataset file at path, see [dataset.OpenFile], onto the set of
+// the context with the given name, one of "training", "tests" or "validation",
+// and records it among the sources. It returns the record.
+func (ctx *Context) Load(set, path string, extra ...string) (nn.DatasetInfo, error) {
+	switch set {
+	case "training", "tests", "validation":
+	default:
+		return nn.DatasetInfo{}, ErrUnknownSet(set)
+	}
+
+	data, err := load_data(path, ctx.NeuralNetwork.Responses(), extra...)
+	if err != nil {
+		return nn.DatasetInfo{}, fmt.Errorf("load data: %w", err)
+	}
+
+	// the file of the data, if any, is only kept along with the data
+	discard := func() {
+		if c, ok := data.(io.Closer); ok {
+			c.Close()
+		}
+	}
+
+	if data.Len() == 0 {
+		discard()
+		return nn.DatasetInfo{Set: set, Path: path, Extra: extra}, nil
+	}
+
+	first := data.Get(0)
+	if e, i := ctx.NeuralNetwork.Features(), first.Values.Rows(); e != i {
+		discard()
+		return nn.DatasetInfo{}, ErrBadInput(e, i)
+	}
+
+	if e, o := ctx.NeuralNetwork.Responses(), first.Label.Rows(); e != o {
+		discard()
+		return nn.DatasetInfo{}, ErrBadOutput(e, o)
+	}
+
+	if names := nn.ClassesOf(data).Names; names != nil {
+		switch current := ctx.NeuralNetwork.Classes().Names; {
+		case current == nil:
+			ctx.NeuralNetwork.SetClassNames(names)
+			ctx.Unsaved = true
+		case !slices.Equal(current, names):
+			discard()
+			return nn.DatasetInfo{}, ErrClassMismatch
+		}
+	}
+
+	count, hash := nn.Fingerprint(data)
+	info := nn.DatasetInfo{
+		Set:   set,
+		Path:  path,
+		Extra: extra,
+		Count: count,
+		Hash:  hash,
+	}
+	ctx.Sources = append(ctx.Sources, info)
+	ctx.hold(data)
+
+	switch set {
+	case "training":
+		ctx.Training = nn.Concat(ctx.Training, data)
+	case "tests":
+		ctx.Tests = nn.Concat(ctx.Tests, data)
+	case "validation":
+		ctx.Validation = nn.Concat(ctx.Validation, data)
+	}
+
+	return info, nil
+}
+
 var (
 	reArgs = regexp.MustCompile(`\S+`)
 	reName = regexp.MustCompile(`[a-z\-]+`)
+
+	reVariable  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	reReference = regexp.MustCompile(`\$(?:[A-Za-z_][A-Za-z0-9_]*|\{[A-Za-z_][A-Za-z0-9_]*\})`)
 )
 
 var directives = map[string]Directive{
-	"help":   CommandHelp,
-	"new":    CommandNew,
-	"list":   CommandList,
-	"focus":  CommandFocus,
-	"load":   CommandLoad,
-	"store":  CommandStore,
-	"train":  CommandTrain,
-	"cycle":  CommandCycle,
-	"status": CommandStatus,
-	"rate":   CommandRate,
-	"clear":  CommandClear,
-	"exit":   CommandQuit,
-	"quit":   CommandQuit,
-}
-
-func New(w io.Writer, r io.Reader) {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	"help":        CommandHelp,
+	"new":         CommandNew,
+	"list":        CommandList,
+	"focus":       CommandFocus,
+	"clone":       CommandClone,
+	"rename":      CommandRename,
+	"drop":        CommandDrop,
+	"compare":     CommandCompare,
+	"load":        CommandLoad,
+	"store":       CommandStore,
+	"convert":     CommandConvert,
+	"train":       CommandTrain,
+	"cycle":       CommandCycle,
+	"graph":       CommandGraph,
+	"plot":        CommandPlot,
+	"log":         CommandLog,
+	"tensorboard": CommandTensorBoard,
+	"bg":          CommandBg,
+	"jobs":        CommandJobs,
+	"fg":          CommandFg,
+	"pause":       CommandPause,
+	"resume":      CommandResume,
+	"kill":        CommandKill,
+	"snapshot":    CommandSnapshot,
+	"snapshots":   CommandSnapshots,
+	"revert":      CommandRevert,
+	"undo":        CommandUndo,
+	"status":      CommandStatus,
+	"evaluate":    CommandEvaluate,
+	"mistakes":    CommandMistakes,
+	"classify":    CommandClassify,
+	"show":        CommandShow,
+	"inspect":     CommandInspect,
+	"rate":        CommandRate,
+	"task":        CommandTask,
+	"info":        CommandInfo,
+	"note":        CommandNote,
+	"session":     CommandSession,
+	"set":         CommandSet,
+	"augment":     CommandAugment,
+	"clear":       CommandClear,
+	"exit":        CommandQuit,
+	"quit":        CommandQuit,
+}
 
-	state := State{
-		ctxs:    make(map[string]*Context),
-		signals: signals,
+// New runs the REPL, reading directives from r until it is exhausted or one of
+// them quits. Prompts and confirmations are skipped if r is not a terminal,
+// in which case errors abort the REPL after set -e, and the error is returned.
+func New(w io.Writer, r io.Reader) error {
+	state := new_state(is_terminal(r))
+	defer state.close()
+
+	var lines line_reader
+	if e, ok := new_editor(state, w, r); ok {
+		defer e.Close()
+		lines = e
+	} else {
+		reader := bufio.NewReader(r)
+		lines = plain_reader{reader, w, state.interactive}
+		r = reader
 	}
 
-	reader := bufio.NewReader(r)
 	for {
-		fmt.Fprintf(w, "%s> ", state.focus)
-		bytes, _, err := reader.ReadLine()
-		if err != nil {
-			fmt.Fprintln(w, err)
-			continue
-		}
+		state.report(w)
 
-		matches := reArgs.FindAllString(string(bytes), -1)
-		if len(matches) == 0 {
-			continue
+		line, err := lines.ReadLine(fmt.Sprintf("%s> ", state.focus))
+		if err == io.EOF {
+			if state.interactive {
+				fmt.Fprintln(w)
+			}
+			return nil
 		}
-
-		directive, in := directives[matches[0]]
-		if !in {
-			fmt.Fprintf(w, "unknown directive %q\n", matches[0])
-			continue
+		if err != nil {
+			return err
 		}
 
-		if err := directive(&state, w, r, matches[1:]...); err != nil {
+		if err := state.exec(w, r, line); err != nil {
 			if err == QuitMessage {
-				break
+				return nil
 			}
 
 			fmt.Fprintln(w, err)
-			continue
+			if state.errexit && !state.interactive {
+				return err
+			}
 		}
 	}
 }
 
+func new_state(interactive bool) *State {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	return &State{
+		ctxs:        make(map[string]*Context),
+		vars:        make(map[string]string),
+		jobs:        make(map[int]*job),
+		screen:      default_screen(),
+		interactive: interactive,
+		signals:     signals,
+	}
+}
+
+// exec runs a single line, after stripping its comment and substituting its
+// variables. Blank lines are ignored.
+func (s *State) exec(w io.Writer, r io.Reader, line string) error {
+	line, err := s.substitute(strip_comment(line))
+	if err != nil {
+		return err
+	}
+
+	matches := reArgs.FindAllString(line, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	directive, in := directives[matches[0]]
+	if !in {
+		return ErrUnknownDirective(matches[0])
+	}
+
+	return directive(s, w, r, matches[1:]...)
+}
+
 var (
 	QuitMessage = errors.New("quit")
 
 	ErrNilContext      = errors.New("nil context")
 	ErrContextNotFound = errors.New("context not found")
+	ErrContextExists   = errors.New("there is already a context with that name")
 
 	ErrNewMissingArgs       = errors.New("bad args: new <name> { <dims> }")
 	ErrNewMissingDimensions = errors.New("bad args: there must be at least two dimensions")
-	ErrLoadMissingArgs      = errors.New("bad args: load ( model <name> | training | tests ) <path>")
-	ErrStoreMissingArgs     = errors.New("bad args: store model <path>")
+	ErrLoadMissingArgs      = errors.New("bad args: load ( model <name> | training | tests | validation ) <path> [<labels>]")
+	ErrStoreMissingArgs     = errors.New("bad args: store model <path> [float64 | float32]")
+	ErrConvertMissingArgs   = errors.New("bad args: convert <source> <destination> [uint8 | float32]")
+	ErrAugmentMissingArgs   = errors.New("bad args: augment [ add <kind> { <params> } | remove <index> | clear | preview <index> ]")
 	ErrTrainMissingArgs     = errors.New("bad args: train <size>")
 	ErrCycleMissingArgs     = errors.New("bad args: cycle <size> <iterations>")
-
-	ErrBadInput  = func(e, g int) error { return fmt.Errorf("input length: expected %d, got %d", e, g) }
-	ErrBadOutput = func(e, g int) error { return fmt.Errorf("output length: expected %d, got %d", e, g) }
+	ErrClassifyMissingArgs  = errors.New("bad args: classify <path> [deskew]")
+	ErrNoteMissingArgs      = errors.New("bad args: note { <word> }")
+	ErrSessionMissingArgs   = errors.New("bad args: session ( save <path> [snapshots] | load <path> )")
+	ErrSourceMissingArgs    = errors.New("bad args: source <path>")
+	ErrShowMissingArgs      = errors.New("bad args: show ( training | tests | validation ) <index> [<mode>]")
+	ErrInspectMissingArgs   = errors.New("bad args: inspect ( training | tests | validation ) [summary | labels | mean | variance] [<mode>]")
+	ErrCloneMissingArgs     = errors.New("bad args: clone <source> <destination>")
+	ErrRenameMissingArgs    = errors.New("bad args: rename <name> <new name>")
+	ErrDropMissingArgs      = errors.New("bad args: drop <name>")
+	ErrCompareMissingArgs   = errors.New("bad args: compare <name> <name> [training | tests | validation]")
+	ErrGraphMissingArgs     = errors.New("bad args: graph [ show { <metric> } | scale ( linear | log ) | smooth <n> ]")
+	ErrPlotMissingArgs      = errors.New("bad args: plot [export <path>]")
+	ErrRevertMissingArgs    = errors.New("bad args: revert <name>")
+	ErrBgMissingArgs        = errors.New("bad args: bg ( train | cycle ) <size> [<iterations>]")
+	ErrJobMissingArgs       = func(directive string) error { return fmt.Errorf("bad args: %s <id>", directive) }
+
+	ErrEmptyDataset  = errors.New("there are no samples in the dataset")
+	ErrBadTopK       = errors.New("bad k: k must be positive")
+	ErrEmptyHistory  = errors.New("there is no history, see cycle")
+	ErrClassMismatch = errors.New("the class names of the dataset differ from those of the model")
+
+	ErrCycleNotInteractive = errors.New("cycle needs an interactive terminal, see train or bg")
+
+	ErrContextBusy = errors.New("the context has running jobs, kill them first")
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("the job has already finished")
+
+	ErrDiverged = func(cycle int) error { return fmt.Errorf("the training diverged at cycle %d, see undo", cycle) }
+
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrNothingToUndo    = errors.New("there is nothing to undo")
+	ErrSnapshotMismatch = errors.New("the snapshot does not match the dimensions of the model")
+
+	ErrSessionVersion   = errors.New("unsupported session version")
+	ErrSessionDuplicate = errors.New("duplicate context name")
+	ErrSourceDepth      = fmt.Errorf("scripts may only be sourced %d levels deep", max_depth)
+
+	ErrTaskUnsupported = func(task nn.Task) error { return fmt.Errorf("not supported for %s tasks", task) }
+
+	ErrOutOfRange = func(i, n int) error { return fmt.Errorf("index %d out of range [0, %d)", i, n) }
+	ErrBadInput   = func(e, g int) error { return fmt.Errorf("input length: expected %d, got %d", e, g) }
+	ErrBadOutput  = func(e, g int) error { return fmt.Errorf("output length: expected %d, got %d", e, g) }
 
 	ErrUnknownDirective = func(directive string) error { return fmt.Errorf("unknown directive %q", directive) }
 	ErrBadName          = errors.New("bad name: name must only include lowercase latin letters and dashes (-)")
 	ErrBadNumber        = func(err error) error { return fmt.Errorf("bad number: %w", err) }
+	ErrBadParameter     = func(p string, lo, hi float64) error { return fmt.Errorf("bad %s: must be in [%g, %g]", p, lo, hi) }
+	ErrBadInteger       = func(p string) error { return fmt.Errorf("bad %s: must be a whole number", p) }
+	ErrBadVariable      = errors.New("bad name: variable names must only include latin letters, digits and underscores (_), and not start with a digit")
+
+	ErrUnknownMetric     = func(name string) error { return fmt.Errorf("unknown metric %q", name) }
+	ErrUnknownSet        = func(name string) error { return fmt.Errorf("unknown set %q", name) }
+	ErrUndefinedVariable = func(name string) error { return fmt.Errorf("undefined variable %q", name) }
 )
 
 func CommandHelp(state *State, w io.Writer, _ io.Reader, args ...string) error {
@@ -170,17 +486,21 @@ func CommandNew(state *State, w io.Writer, r io.Reader, args ...string) error {
 
 	nn := nn.New(dims...)
 
-	if ctx, in := state.ctxs[name]; in && ctx.Unsaved {
-		overwrite, err := overwrite_loop(w, r, name)
+	if state.busy(name) {
+		return ErrContextBusy
+	}
+
+	if ctx, in := state.ctxs[name]; in && ctx.unsaved() {
+		overwrite, err := state.overwrite(w, r, name)
 		if err != nil || !overwrite {
 			return nil
 		}
 	}
 
-	state.ctxs[name] = &Context{
-		NeuralNetwork: nn,
-		Unsaved:       true,
-	}
+	ctx := NewContext(nn)
+	ctx.Unsaved = true
+
+	state.put(name, ctx)
 
 	state.focus = name
 	return nil
@@ -194,7 +514,7 @@ func CommandList(state *State, w io.Writer, _ io.Reader, args ...string) error {
 
 	slices.Sort(keys)
 	for _, key := range keys {
-		if state.ctxs[key].Unsaved {
+		if state.ctxs[key].unsaved() {
 			fmt.Fprintln(w, key+"*")
 		} else {
 			fmt.Fprintln(w, key)
@@ -237,62 +557,38 @@ func CommandLoad(state *State, w io.Writer, r io.Reader, args ...string) error {
 		name := args[1]
 		path := args[2]
 
-		nn, err := load_model(path)
+		network, err := nn.LoadFile(path)
 		if err != nil {
 			return fmt.Errorf("load model: %w", err)
 		}
 
-		if ctx, in := state.ctxs[name]; in && ctx.Unsaved {
-			overwrite, err := overwrite_loop(w, r, name)
+		if state.busy(name) {
+			return ErrContextBusy
+		}
+
+		if ctx, in := state.ctxs[name]; in && ctx.unsaved() {
+			overwrite, err := state.overwrite(w, r, name)
 			if err != nil || !overwrite {
 				return nil
 			}
 		}
 
-		state.ctxs[name] = &Context{
-			NeuralNetwork: nn,
-			Unsaved:       false,
-		}
+		state.put(name, NewContext(network))
 
 		state.focus = name
 		return nil
 	}
 
-	switch directive {
-	case "training", "tests":
-	default:
-		return ErrUnknownDirective(directive)
-	}
-
 	ctx := state.Focused()
 	if ctx == nil {
 		return ErrNilContext
 	}
-	path := args[1]
 
-	data, err := load_data(path)
-	if err != nil {
-		return fmt.Errorf("load data: %w", err)
-	}
-	if len(data) == 0 {
-		return nil
-	}
-
-	if e, i := ctx.NeuralNetwork.Features(), data[0].Values.Rows(); e != i {
-		return ErrBadInput(e, i)
-	}
-
-	if e, o := ctx.NeuralNetwork.Responses(), data[0].Label.Rows(); e != o {
-		return ErrBadInput(e, o)
-	}
-
-	if directive == "training" {
-		ctx.Training = append(ctx.Training, data...)
-	} else {
-		ctx.Tests = append(ctx.Tests, data...)
-	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 
-	return nil
+	_, err := ctx.Load(directive, args[1], args[2:]...)
+	return err
 }
 
 func CommandStore(state *State, w io.Writer, r io.Reader, args ...string) error {
@@ -304,6 +600,9 @@ func CommandStore(state *State, w io.Writer, r io.Reader, args ...string) error
 	if ctx == nil {
 		return ErrNilContext
 	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	path := args[1]
 
 	directive := args[0]
@@ -311,7 +610,17 @@ func CommandStore(state *State, w io.Writer, r io.Reader, args ...string) error
 		return ErrUnknownDirective(directive)
 	}
 
-	if err := store_model(path, ctx.NeuralNetwork); err != nil {
+	dtype := nn.DTypeFloat64
+	if len(args) >= 3 {
+		var err error
+		dtype, err = nn.ParseDType(args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx.Sync()
+	if err := nn.StoreFile(path, ctx.NeuralNetwork, dtype); err != nil {
 		return fmt.Errorf("store model: %w", err)
 	}
 
@@ -319,6 +628,36 @@ func CommandStore(state *State, w io.Writer, r io.Reader, args ...string) error
 	return nil
 }
 
+func CommandConvert(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrConvertMissingArgs
+	}
+	src, dst := args[0], args[1]
+
+	data, err := load_data(src, 0)
+	if err != nil {
+		return fmt.Errorf("load data: %w", err)
+	}
+	if c, ok := data.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	dtype := dataset.BestDType(data)
+	if len(args) >= 3 {
+		dtype, err = dataset.ParseDType(args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := dataset.StoreFile(dst, data, dtype); err != nil {
+		return fmt.Errorf("store data: %w", err)
+	}
+
+	fmt.Fprintf(w, "Converted %d samples.\n", data.Len())
+	return nil
+}
+
 func CommandTrain(state *State, w io.Writer, _ io.Reader, args ...string) error {
 	if len(args) < 1 {
 		return ErrTrainMissingArgs
@@ -329,37 +668,44 @@ func CommandTrain(state *State, w io.Writer, _ io.Reader, args ...string) error
 		return ErrNilContext
 	}
 
-	size, err := strconv.Atoi(args[0])
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	size, iterations, err := parse_batch(args)
 	if err != nil {
-		return ErrBadNumber(err)
+		return err
+	}
+	if iterations < 1 {
+		return nil
 	}
 
-	iterations := 1
-	if len(args) >= 2 {
-		iterations, err = strconv.Atoi(args[1])
-		if err != nil {
-			return ErrBadNumber(err)
+	ctx.checkpoint("train", args...)
+
+	ctx.BatchSize = size
+	t := ctx.trainer(stop_on_divergence(&err))
+
+	progress := state.interactive && iterations > 1
+	for i := range iterations {
+		if t.Stopped() {
+			break
 		}
-		if iterations < 1 {
-			return nil
+		if progress {
+			fmt.Fprintf(w, "\r%d/%d", i+1, iterations)
 		}
-	}
 
-	if iterations == 1 {
-		learn_batch(ctx, size)
 		ctx.Cycle++
-	} else {
-		for i := range iterations {
-			fmt.Fprintf(w, "\r%d/%d", i+1, iterations)
-
-			ctx.Cycle++
-			learn_batch(ctx, size)
-		}
+		learn_batch(ctx, t, size)
+	}
+	if progress {
 		fmt.Fprintln(w)
 	}
 
-	ctx.Unsaved = true
-	return nil
+	// train does not measure as it goes, so the log gets a record per call
+	if ctx.log != nil {
+		measure(ctx, t)
+	}
+
+	return err
 }
 
 func CommandCycle(state *State, w io.Writer, _ io.Reader, args ...string) error {
@@ -367,94 +713,211 @@ func CommandCycle(state *State, w io.Writer, _ io.Reader, args ...string) error
 		return ErrCycleMissingArgs
 	}
 
+	// the screen of cycle needs a terminal, and runs until ^C
+	if !state.interactive {
+		return ErrCycleNotInteractive
+	}
+
 	ctx := state.Focused()
 	if ctx == nil {
 		return ErrNilContext
 	}
 
-	size, err := strconv.Atoi(args[0])
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	size, iterations, err := parse_batch(args)
 	if err != nil {
-		return ErrBadNumber(err)
+		return err
 	}
-
-	iterations := 1
-	if len(args) >= 2 {
-		iterations, err = strconv.Atoi(args[1])
-		if err != nil {
-			return ErrBadNumber(err)
-		}
-		if iterations < 1 {
-			return nil
-		}
+	if iterations < 1 {
+		return nil
 	}
 
+	ctx.checkpoint("cycle", args...)
+
+	ctx.BatchSize = size
+
 	io.WriteString(w, "\033[?1049h")
 	defer io.WriteString(w, "\033[?1049l")
 
-	var closed_for_test bool
-	test := make(chan int, 1)
+	// the network is only measured once the screen shows the previous
+	// measure, records are sent by the hook of the trainer
+	records := make(chan nn.Record, 1)
+	ready := make(chan struct{}, 1)
+	ready <- struct{}{}
 
-	quit := make(chan struct{}, 1)
-	var quitting bool
+	t := ctx.trainer(
+		stop_on_divergence(&err),
+		nn.Hooks{Evaluate: func(_ *nn.Trainer, e nn.Evaluated) { records <- record_of(e) }},
+	)
 
 	go func() {
-		for {
-			select {
-			default:
-				ctx.Cycle++
+		defer close(records)
 
-				for range iterations {
-					learn_batch(ctx, size)
-				}
+		for !t.Stopped() {
+			ctx.Cycle++
 
-				if !closed_for_test {
-					test <- ctx.Cycle
-					closed_for_test = true
+			for range iterations {
+				if t.Stopped() {
+					break
 				}
-
-			case <-quit:
-				close(test)
+				learn_batch(ctx, t, size)
+			}
+			if t.Stopped() {
 				return
 			}
+
+			select {
+			case <-ready:
+				measure(ctx, t)
+			default:
+			}
 		}
 	}()
 
-	go func() {
-		<-state.signals
-
-		quit <- struct{}{}
-		quitting = true
+	done := make(chan struct{})
+	defer close(done)
 
-		fmt.Print("^C")
+	var quitting atomic.Bool
+	go func() {
+		select {
+		case <-state.signals:
+			t.Stop()
+			quitting.Store(true)
+			fmt.Print("^C")
 
-		ctx.Unsaved = true
+		case <-done:
+		}
 	}()
 
-	for cycle := range test {
-		print_screen(w, ctx, cycle)
-		if quitting {
+	for record := range records {
+		render_screen(w, ctx, record, state.screen)
+		if quitting.Load() {
 			fmt.Print("\r^C")
 		}
 
-		closed_for_test = false
+		ready <- struct{}{}
 	}
 
-	return nil
+	return err
 }
 
-func print_screen(w io.Writer, ctx *Context, cycle int) {
-	correct, cost := ctx.NeuralNetwork.Performance(ctx.Tests)
+// parse_batch parses the batch size and the number of iterations, which is one
+// if not given, of the train and cycle directives.
+func parse_batch(args []string) (size, iterations int, err error) {
+	size, err = strconv.Atoi(args[0])
+	if err != nil {
+		return 0, 0, ErrBadNumber(err)
+	}
+
+	iterations = 1
+	if len(args) >= 2 {
+		iterations, err = strconv.Atoi(args[1])
+		if err != nil {
+			return 0, 0, ErrBadNumber(err)
+		}
+	}
+
+	return size, iterations, nil
+}
+
+// measure_samples is how many training samples the training cost is measured
+// against.
+const measure_samples = 1000
+
+// measure evaluates the context against its test and validation data, and
+// part of its training data, at most measure_samples samples from a random
+// offset, at its current cycle. The record is kept by the hooks of the
+// trainer, see [Context.trainer].
+func measure(ctx *Context, t *nn.Trainer) nn.Record {
+	datasets := map[string]nn.Dataset{
+		"tests":      ctx.Tests,
+		"validation": ctx.Validation,
+	}
+	if n := ctx.Training.Len(); n > 0 {
+		size := min(n, measure_samples)
+		from := rand.IntN(n - size + 1)
+		datasets["training"] = nn.Slice(ctx.Training, from, from+size)
+	}
+
+	t.Cycle, t.Rate = ctx.Cycle, ctx.LearningRate
+	return record_of(t.Evaluate(datasets, 1))
+}
+
+// record_of returns the record of an evaluation of the datasets of measure.
+func record_of(e nn.Evaluated) nn.Record {
+	record := nn.Record{Cycle: e.Cycle, LearningRate: e.Rate}
+
+	if eval, in := e.Evaluations["tests"]; in {
+		record.Cost, record.Accuracy = eval.Cost, eval.Accuracy()
+	}
+	if eval, in := e.Evaluations["training"]; in {
+		record.TrainingCost = eval.Cost
+	}
+	if eval, in := e.Evaluations["validation"]; in {
+		record.ValidationCost = eval.Cost
+	}
+
+	return record
+}
+
+// trainer returns a trainer of the network of the context, which marks it
+// as unsaved after every batch, keeps the record of every evaluation, logs
+// the metrics and writes the events of the training, if the context does so,
+// and notifies the given observers too.
+func (ctx *Context) trainer(observers ...nn.Observer) *nn.Trainer {
+	hooks := nn.Hooks{
+		BatchEnd: func(*nn.Trainer, nn.BatchEnd) { ctx.Unsaved = true },
+		Evaluate: func(_ *nn.Trainer, e nn.Evaluated) { ctx.Evolution = append(ctx.Evolution, record_of(e)) },
+	}
+
+	t := nn.Trainer{
+		Network:   ctx.NeuralNetwork,
+		Rate:      ctx.LearningRate,
+		Cycle:     ctx.Cycle,
+		Observers: []nn.Observer{hooks},
+	}
+	if ctx.log != nil {
+		t.Observers = append(t.Observers, ctx.log)
+	}
+	if ctx.board != nil {
+		t.Observers = append(t.Observers, ctx.board)
+	}
+	t.Observers = append(t.Observers, observers...)
+
+	return &t
+}
+
+// stop_on_divergence returns hooks that stop the training once it diverges,
+// setting err to tell so.
+func stop_on_divergence(err *error) nn.Hooks {
+	return nn.Hooks{Divergence: func(t *nn.Trainer, d nn.Divergence) {
+		*err = ErrDiverged(d.Cycle)
+		t.Stop()
+	}}
+}
+
+// render_screen shows the given record, along with the history of the
+// context, plotted as configured, filling the screen.
+func render_screen(w io.Writer, ctx *Context, record nn.Record, screen screen_settings) {
+	total := ctx.Tests.Len()
+	correct := int(math.Round(record.Accuracy * float64(total)))
 
 	var b strings.Builder
 
 	fmt.Fprint(&b, "\033[1;1H\033[2J")
-	fmt.Fprintf(&b, "Cycle %d\n", cycle)
+	fmt.Fprintf(&b, "Cycle %d\n", record.Cycle)
 	fmt.Fprintf(&b, "Learning rate: %f\n", ctx.LearningRate)
 
 	fmt.Fprint(&b, "\nTests:\n")
-	fmt.Fprintf(&b, "\tCorrect: %d/%d\n", correct, len(ctx.Tests))
-	fmt.Fprintf(&b, "\tCost: %f\n", cost)
-	fmt.Fprintf(&b, "\tError rate: %.2f%%\n", 100*(1-float64(correct)/float64(len(ctx.Tests))))
+	if ctx.NeuralNetwork.Task() == nn.TaskRegression {
+		fmt.Fprintf(&b, "\tCost: %f\n", record.Cost)
+	} else {
+		fmt.Fprintf(&b, "\tCorrect: %d/%d\n", correct, total)
+		fmt.Fprintf(&b, "\tCost: %f\n", record.Cost)
+		fmt.Fprintf(&b, "\tError rate: %.2f%%\n", 100*(1-record.Accuracy))
+	}
 
 	status := b.String()
 
@@ -473,10 +936,7 @@ func print_screen(w io.Writer, ctx *Context, cycle int) {
 		return
 	}
 
-	ctx.Evolution = append(ctx.Evolution, cost)
-
-	_, graph := Graph(ctx.Evolution, width, height-strings.Count(status, "\n"))
-	b.WriteString(graph)
+	b.WriteString(screen.plot(ctx, width, height-strings.Count(status, "\n")))
 
 	fmt.Print(b.String())
 }
@@ -487,9 +947,17 @@ func CommandStatus(state *State, w io.Writer, _ io.Reader, args ...string) error
 		return ErrNilContext
 	}
 
-	total := len(ctx.Tests)
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	total := ctx.Tests.Len()
 	correct, cost := ctx.NeuralNetwork.Performance(ctx.Tests)
 
+	if ctx.NeuralNetwork.Task() == nn.TaskRegression {
+		fmt.Fprintf(w, "Total: %d\n\nCost: %f\n", total, cost)
+		return nil
+	}
+
 	fmt.Fprintf(w,
 		"Correct: %d\nIncorrect: %d\nTotal: %d\nPerformance: %.2f%%\n\nCost: %f\n",
 		correct, total-correct, total, 100*float64(correct)/float64(total), cost,
@@ -504,6 +972,9 @@ func CommandRate(state *State, w io.Writer, _ io.Reader, args ...string) error {
 		return ErrNilContext
 	}
 
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
 	if len(args) < 1 {
 		fmt.Fprintf(w, "Learning rate: %f\n", ctx.LearningRate)
 		return nil
@@ -518,41 +989,109 @@ func CommandRate(state *State, w io.Writer, _ io.Reader, args ...string) error {
 	return nil
 }
 
+func CommandTask(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if len(args) < 1 {
+		fmt.Fprintf(w, "Task: %s\n", ctx.NeuralNetwork.Task())
+		return nil
+	}
+
+	task, err := nn.ParseTask(args[0])
+	if err != nil {
+		return err
+	}
+
+	ctx.NeuralNetwork.SetTask(task)
+	ctx.Unsaved = true
+	return nil
+}
+
 func CommandClear(s *State, w io.Writer, _ io.Reader, _ ...string) error {
 	w.Write([]byte{0o33, 'c'})
 	return nil
 }
 
 func CommandQuit(state *State, w io.Writer, r io.Reader, _ ...string) error {
-	if !state.Unsaved() {
+	message := "There are unsaved changes, do you want to quit"
+	switch {
+	case state.busy(""):
+		message = "There are running jobs, do you want to quit"
+	case !state.Unsaved():
 		return QuitMessage
 	}
 
+	quit, err := state.confirm(w, r, message)
+	if err != nil {
+		return err
+	}
+	if !quit {
+		return nil
+	}
+
+	return QuitMessage
+}
+
+func read_line(r io.Reader) (string, error) {
+	var b strings.Builder
+
+	var buf [1]byte
 	for {
-		var char rune
-		fmt.Fprint(w, "There are unsaved changes, do you want to quit ([y] or n)? ")
-		n, err := fmt.Fscanf(r, "%c\n", &char)
+		_, err := r.Read(buf[:])
 		if err != nil {
-			return err
+			return b.String(), err
 		}
-		if n == 0 {
-			return QuitMessage
+		if buf[0] == '\n' {
+			return strings.TrimSuffix(b.String(), "\r"), nil
 		}
 
-		switch char {
-		case 'Y', 'y':
-			return QuitMessage
+		b.WriteByte(buf[0])
+	}
+}
 
-		case 'N', 'n':
-			return nil
-		}
+// is_terminal tells whether r reads from a terminal.
+func is_terminal(r io.Reader) bool {
+	f, ok := r.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+func term_size(w io.Writer) (width, height int, ok bool) {
+	wf, ok := w.(interface {
+		io.Writer
+		Fd() uintptr
+	})
+	if !ok {
+		return 0, 0, false
+	}
+
+	width, height, err := term.GetSize(int(wf.Fd()))
+	if err != nil {
+		return 0, 0, false
 	}
+
+	return width, height, true
+}
+
+func (s *State) overwrite(w io.Writer, r io.Reader, name string) (bool, error) {
+	return s.confirm(w, r, fmt.Sprintf("There are unsaved changes, do you want to overwrite %q", name))
 }
 
-func overwrite_loop(w io.Writer, r io.Reader, name string) (bool, error) {
+// confirm asks a yes or no question until answered, yes by default, which is
+// also the answer if there is no one to ask.
+func (s *State) confirm(w io.Writer, r io.Reader, question string) (bool, error) {
+	if !s.interactive {
+		return true, nil
+	}
+
 	for {
 		var char rune
-		fmt.Fprintf(w, "There are unsaved changes, do you want to overwrite %q ([y] or n)? ", name)
+		fmt.Fprintf(w, "%s ([y] or n)? ", question)
 		n, err := fmt.Fscanf(r, "%c\n", &char)
 		if err != nil {
 			return false, err
@@ -571,65 +1110,50 @@ func overwrite_loop(w io.Writer, r io.Reader, name string) (bool, error) {
 	}
 }
 
-func learn_batch(ctx *Context, size int) {
-	if ctx == nil {
-		return
-	}
-
+// learn_batch makes the network of the context learn from a random batch of
+// the given size, with the trainer, at the current cycle and learning rate of
+// the context.
+func learn_batch(ctx *Context, t *nn.Trainer, size int) nn.BatchEnd {
 	batch := ctx.Training
-	if size < len(batch) {
-		offset := rand.IntN(len(batch) - size)
-		batch = batch[offset : offset+size]
-	}
-
-	ctx.NeuralNetwork.Learn(batch, ctx.LearningRate)
-}
-
-func store_model(path string, nn *nn.NeuralNetwork) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
+	if size < batch.Len() {
+		offset := rand.IntN(batch.Len() - size)
+		batch = nn.Slice(batch, offset, offset+size)
 	}
-	defer f.Close()
 
-	j, err := json.Marshal(nn)
-	if err != nil {
-		return err
+	if len(ctx.Augmentation) > 0 {
+		batch = augment.Dataset(batch, ctx.Augmentation)
 	}
 
-	_, err = f.Write(j)
-	return err
+	t.Cycle, t.Rate = ctx.Cycle, ctx.LearningRate
+	return t.Batch(batch)
 }
 
-func load_data(path string) ([]nn.Sample, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	return dataset.LoadFromJSON(f)
+func load_data(path string, classes int, extra ...string) (nn.Dataset, error) {
+	return dataset.OpenFile(path, classes, extra...)
 }
 
-func load_model(path string) (*nn.NeuralNetwork, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+// class_labels returns the names of the classes of the network, or their
+// numbers for those without a name.
+func class_labels(network *nn.NeuralNetwork) []string {
+	classes := network.Classes()
 
-	var nn nn.NeuralNetwork
-	if err := json.NewDecoder(f).Decode(&nn); err != nil {
-		return nil, err
+	labels := make([]string, classes.Count)
+	for i := range labels {
+		labels[i] = classes.Name(i)
 	}
 
-	return &nn, nil
+	return labels
 }
 
 const help = `NN Digits v0.0.3
 
 NN Digits is an interactive shell for training a basic Multilayer Perceptron.
 
+Lines are edited with the arrows, home and end, previous lines are recalled
+with up and down, or searched backwards with ^R, and kept in
+~/.nn_digits_history. Tab completes directives, model names, the keywords
+directives take and file paths.
+
 	new <name> { <dims> }
 		creates a new neural network with the given dimensions and
 		puts it on focus.
@@ -643,33 +1167,186 @@ NN Digits is an interactive shell for training a basic Multilayer Perceptron.
 	focus <name>
 		changes the focused model.
 
-	load training <path>
+	clone <source> <destination>
+		copies the model <source> onto <destination>, along with its
+		datasets, hyperparameters and training state, and focuses
+		it, so that experiments may branch off one another.
+
+	rename <name> <new name>
+		renames the model <name>.
+
+	drop <name>
+		removes the model <name>. If there are any unsaved changes,
+		it will ask if you still want to drop it.
+
+	compare <name> <name> [training | tests | validation]
+		shows the metrics of both models side by side, on the test
+		set of the first one unless another is given, and, if their
+		dimensions are the same, the Euclidean distance between their
+		weights, and between their biases, in each layer.
+
+	load training <path> [<labels>]
 		loads training data from the file at <path> onto the focused
 		model. If the data does not matches the size of the input and
-		output layer sizes, it will be rejected.
-
-	load tests <path>
+		output layer sizes, it will be rejected. The format, CSV,
+		JSON, IDX or binary, is detected by the extension or the
+		content, and gzip compressed files are read transparently,
+		binary files are memory-mapped rather than loaded. IDX images
+		take their labels from <labels>, if given, or else from the
+		file named after them, e.g., train-labels-idx1-ubyte for
+		train-images-idx3-ubyte. If <path> is a directory, it is
+		expected to hold PNG, JPEG or GIF images in subdirectories
+		named after their labels, e.g., <path>/7/scan.png; if those
+		are not numbers, they are taken as class names, in lexical
+		order. Class names carried by the data are adopted by a model
+		without any, and data whose class names differ from the
+		model's is rejected.
+
+	load tests <path> [<labels>]
 		loads test data from the file at <path> onto the focused
-		model. If the data does not matches the size of the input and
-		output layer sizes, it will be rejected.
+		model, see load training.
+
+	load validation <path> [<labels>]
+		loads validation data from the file at <path> onto the
+		focused model, see load training.
 
 	load model <name> <path>
 		loads a model from the file at <path> and puts it on focus.
 
-	store model <path>
+	session save <path> [snapshots]
+		stores every model, along with its training state, e.g.,
+		learning rate, cycle count and cost history, the paths of its
+		datasets and which model is focused, on the file at <path>.
+		Datasets are referenced, not embedded. Named snapshots are
+		only stored if asked for.
+
+	session load <path>
+		replaces every model by those in the session file at <path>,
+		reloading their datasets, and picks up where the session was
+		left.
+
+	store model <path> [float64 | float32]
 		stores a model on the give file path. This might be
-		destructive.
+		destructive. Models are stored in a binary format, with a
+		version and a checksum, and weights as float64, unless
+		stated, or exported as JSON if <path> ends in .json. Both
+		are read by load model.
+
+	convert <source> <destination> [uint8 | float32]
+		converts the dataset at <source>, of any format accepted by
+		load training, into <destination>. If <destination> ends in
+		.json, it is stored as JSON, otherwise in the binary format,
+		which loads much faster. Values are stored as uint8 if that
+		is lossless, or as float32 otherwise, unless stated.
 
 	status
 		shows the current performance of the model agaings its test
 		data.
 
+	evaluate [training | tests | validation] [<k>]
+		evaluates the model against one of its datasets, tests by
+		default, showing the confusion matrix as a heatmap, the
+		precision, recall and F1 of each class, their macro and micro
+		averages, the top-<k> accuracy (top-3 by default) and the
+		log-loss. Multi-label models show the exact match ratio and
+		the Hamming loss instead of the matrix and the accuracies, and
+		regression models, the MSE, MAE and R².
+
+	mistakes [<n>]
+		lists the misclassified test samples, the most confident
+		mistakes first, showing each sample alongside its label, the
+		predicted class and the confidence of the prediction, <n>
+		samples at a time (8 by default).
+
+	classify <path> [deskew]
+		classifies the PNG, JPEG or GIF image at <path> with the
+		focused model, after normalizing it as the MNIST digits
+		were, optionally deskewing it, and shows the normalized image
+		alongside the probability of each class.
+
+	show ( training | tests | validation ) <index> [<mode>]
+		shows the sample at <index> of the given dataset as an
+		image. <mode> is one of blocks (default), braille, ascii or
+		ansi, the latter using the 256-color grayscale.
+
+	inspect ( training | tests | validation ) [summary]
+		shows the number of samples, features and labels, the range
+		of the values, the number of duplicated samples and the
+		label distribution of the given dataset.
+
+	inspect ( training | tests | validation ) labels
+		shows the label distribution of the given dataset.
+
+	inspect ( training | tests | validation ) ( mean | variance ) [<mode>]
+		shows the per-pixel mean or variance of the given dataset as
+		an image, see show for <mode>.
+
 	rate
 		shows the current learning rate of the focused model.
 
 	rate <rate>
 		changes the learning rate of the focused model.
 
+	task
+		shows the task of the focused model.
+
+	task ( classification | multilabel | regression )
+		changes the task of the focused model, i.e., the activation
+		of its output layer and how it is scored: a softmax picking a
+		single class, a sigmoid per class, any number of which may be
+		predicted, or the identity, predicting continuous values.
+
+	info
+		shows the focused model and its metadata: when it was
+		created, how many cycles it was trained for, the learning
+		rate, batch size and augmentations in effect, its cost and
+		accuracy history, the datasets it was loaded with, identified
+		by their sample count and SHA-256, and notes. The metadata is
+		stored along with the model, and picked up when it is loaded.
+
+	note { <word> }
+		appends a line of free-form notes to the focused model.
+
+	augment
+		lists the augmentation pipeline of the focused model, which
+		randomly transforms every training sample on the fly during
+		train and cycle.
+
+	augment add <kind> { <params> }
+		appends a transformation to the augmentation pipeline, where
+		<kind> and its <params>, which default as shown, are one of:
+
+		    affine <rotate> <scale> <shear> <translate> (10 .1 .1 2)
+			rotates by up to <rotate> degrees, scales by up to
+			<scale>, shears by up to <shear> and translates by
+			up to <translate> pixels;
+		    elastic <alpha> <sigma> (8 3)
+			distorts elastically by up to <alpha> pixels,
+			smoothed by <sigma> pixels;
+		    noise <sigma> (.05)
+			adds Gaussian noise of deviation <sigma>;
+		    morphology <max> (1)
+			thickens or thins the strokes by up to <max>
+			pixels; and
+		    erase <probability> <area> (.5 .1)
+			erases, with <probability>, a rectangle of up to
+			<area> of the image.
+
+		Out of range parameters are rejected: angles up to 180,
+		scale up to .9, shear, noise, probability and area up to 1,
+		translate and sigma up to 8, alpha up to 64, and max, a
+		whole number, up to 4.
+
+	augment remove <index>
+		removes a transformation from the augmentation pipeline.
+
+	augment clear
+		removes all transformations from the augmentation pipeline.
+
+	augment preview <index>
+		shows the training sample at <index> next to three random
+		augmentations of it.
+
 	train <size> [<iterations>]
 		trains the network <iterations> times on batches of size
 		<size>, batches are chosen randomly and contiguously out of
@@ -682,7 +1359,118 @@ NN Digits is an interactive shell for training a basic Multilayer Perceptron.
 		test the network and printing to the screen, more than a
 		training cycle might be finished before a test cycle
 		fisishes, the cycle counter may seem to skip numbers. To quit
-		this mode, flash ^C and wait.
+		this mode, flash ^C and wait. The history of the network is
+		plotted below, see graph. Only available in interactive
+		sessions, scripts should use train or bg instead.
+
+	graph
+		shows which metrics are plotted by cycle, and how.
+
+	graph show { <metric> }
+		plots the given metrics, in distinct colors, out of cost, the
+		test cost, training, the cost against part of the training
+		set, validation, the validation cost, accuracy and rate, the
+		learning rate. Only cost is plotted by default.
+
+	graph scale ( linear | log )
+		makes the y axis linear, the default, or logarithmic.
+
+	graph smooth <n>
+		smooths the plotted metrics with a moving average over the
+		last <n> points, 1 for none.
+
+	plot
+		plots the history of the focused model, as cycle does.
+
+	plot export <path>
+		exports the history of the focused model as an SVG chart, if
+		<path> ends in .svg, with a panel for the costs, accuracy and
+		learning rate, or as CSV, if it ends in .csv, with a row for
+		each cycle the model was measured at. Otherwise, both are
+		exported, onto <path>.svg and <path>.csv.
+
+	log [<path> | off]
+		appends the metrics of the focused model onto the file at
+		<path>, as a JSON object per line, every time cycle measures
+		it and at the end of every train, or stops doing so. Each has
+		the time, context, cycle, batch size, learning rate, training,
+		test and validation cost and accuracy, the mean gradient norm
+		since the previous one, and the seconds since logging started.
+		Without arguments, tells where the metrics are logged onto.
+
+	tensorboard [<dir> | off]
+		writes events that TensorBoard reads onto a new event file in
+		<dir>/<name>, <name> being the focused context, while train
+		or cycle trains it, or stops doing so. There are the mean
+		cost and gradient norm of the batches of every cycle, the
+		costs, accuracies and learning rate every time cycle measures
+		the model, and histograms of the weights and biases of every
+		layer, and of their gradients, then and every 100 cycles.
+		Without arguments, tells where the events are written onto.
+
+	bg ( train | cycle ) <size> [<iterations>]
+		runs train or cycle on the focused context in the background,
+		as a job, while the shell takes other directives. The job
+		trains one batch, or cycle, at a time, so directives on its
+		context wait at most that long. Contexts with jobs cannot be
+		replaced, nor sessions loaded, until the jobs finish or are
+		killed. Finished jobs are reported before the next prompt.
+
+	jobs
+		lists the jobs, with their id, state and progress.
+
+	fg <id>
+		brings the job <id> to the foreground, resuming it if paused,
+		and shows its progress, or the screen of cycle, until it
+		finishes. ^C kills it.
+
+	pause <id>
+	resume <id>
+		pauses the job <id> once its current batch is done, or
+		resumes it.
+
+	kill <id>
+		stops the job <id> once its current batch is done.
+
+	snapshot [<name>]
+		takes a snapshot of the weights, biases, hyperparameters and
+		training state of the focused model, named <name>, or after
+		its cycle count, replacing any other by that name.
+
+	snapshots
+		lists the snapshots of the focused model, and what undo would
+		undo.
+
+	revert <name>
+		brings the focused model back to the snapshot <name>.
+
+	undo
+		undoes the last train, cycle, bg or revert on the focused
+		model. Before each, a snapshot is taken automatically, the
+		last 16 are kept.
+
+	source <path>
+		runs the directives in the script at <path>, one per line,
+		as if typed in. Scripts may source others. Errors are shown
+		along with the file and line they happened at, and, after
+		set -e, abort the script. The same scripts can be run
+		unattended with train -f <path>.
+
+	set
+		lists the variables, and whether set -e is in effect.
+
+	set <name> { <word> }
+		sets the variable <name> to the given words, or removes it if
+		there are none. Directives have every $<name> or ${<name>}
+		replaced by the value of the variable, or else of the
+		environment variable, with that name, e.g., rate $RATE, and
+		everything from a word starting with # on is a comment.
+
+	set ( -e | +e )
+		makes errors abort scripts, and the shell if its input is not
+		a terminal, or stops doing so. When the input is not a
+		terminal, e.g., a script is piped in, there are no prompts,
+		and confirmations are answered with yes.
 
 	help
 		shows this screen.
@@ -693,4 +1481,5 @@ NN Digits is an interactive shell for training a basic Multilayer Perceptron.
 	quit
 	exit
 		exits the read-execute-print-loop. If there are any unsaved
-		changes, it will ask if you still want to quit.`
+		changes, or running jobs, it will ask if you still want to
+		quit.`
@@ -0,0 +1,131 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// screen_metric is a metric of the records of a context that may be plotted
+// on the screen of cycle.
+type screen_metric struct {
+	label string
+	color int
+	value func(nn.Record) float64
+}
+
+// screen_metrics are the metrics that may be plotted, metrics not measured
+// for a record are NaN.
+var screen_metrics = map[string]screen_metric{
+	"cost":       {"test cost", 39, func(r nn.Record) float64 { return r.Cost }},
+	"training":   {"training cost", 208, func(r nn.Record) float64 { return measured(r.TrainingCost) }},
+	"validation": {"validation cost", 170, func(r nn.Record) float64 { return measured(r.ValidationCost) }},
+	"accuracy":   {"accuracy", 40, func(r nn.Record) float64 { return r.Accuracy }},
+	"rate":       {"learning rate", 220, func(r nn.Record) float64 { return measured(r.LearningRate) }},
+}
+
+// screen_settings are how the history of a context is plotted on the screen
+// of cycle, see [CommandGraph].
+type screen_settings struct {
+	metrics []string
+	log     bool
+	smooth  int
+}
+
+func default_screen() screen_settings {
+	return screen_settings{metrics: []string{"cost"}}
+}
+
+func CommandGraph(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		scale := "linear"
+		if state.screen.log {
+			scale = "log"
+		}
+
+		fmt.Fprintf(w, "Metrics: %s\n", strings.Join(state.screen.metrics, " "))
+		fmt.Fprintf(w, "Scale: %s\n", scale)
+		fmt.Fprintf(w, "Smoothing: %d\n", max(state.screen.smooth, 1))
+		return nil
+	}
+
+	switch directive := args[0]; directive {
+	case "show":
+		if len(args) < 2 {
+			return ErrGraphMissingArgs
+		}
+
+		for _, name := range args[1:] {
+			if _, in := screen_metrics[name]; !in {
+				return ErrUnknownMetric(name)
+			}
+		}
+		state.screen.metrics = slices.Compact(slices.Clone(args[1:]))
+
+	case "scale":
+		if len(args) < 2 {
+			return ErrGraphMissingArgs
+		}
+
+		switch args[1] {
+		case "linear":
+			state.screen.log = false
+		case "log":
+			state.screen.log = true
+		default:
+			return ErrUnknownDirective(args[1])
+		}
+
+	case "smooth":
+		if len(args) < 2 {
+			return ErrGraphMissingArgs
+		}
+
+		window, err := strconv.Atoi(args[1])
+		if err != nil {
+			return ErrBadNumber(err)
+		}
+		state.screen.smooth = window
+
+	default:
+		return ErrUnknownDirective(directive)
+	}
+
+	return nil
+}
+
+// plot plots the history of the context as configured.
+func (s screen_settings) plot(ctx *Context, width, height int) string {
+	cycles := make([]int, len(ctx.Evolution))
+	for i, record := range ctx.Evolution {
+		cycles[i] = record.Cycle
+	}
+
+	series := make([]Series, 0, len(s.metrics))
+	for _, name := range s.metrics {
+		metric := screen_metrics[name]
+
+		values := make([]float64, len(ctx.Evolution))
+		for i, record := range ctx.Evolution {
+			values[i] = metric.value(record)
+		}
+
+		series = append(series, Series{Name: metric.label, Values: values, Color: metric.color})
+	}
+
+	return Plot(series, cycles, width, height, PlotOptions{Log: s.log, Smooth: s.smooth})
+}
+
+// measured returns v, or NaN if it is zero, i.e., it was not measured.
+func measured(v float64) float64 {
+	if v == 0 {
+		return math.NaN()
+	}
+
+	return v
+}
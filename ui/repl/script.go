@@ -0,0 +1,151 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// max_depth bounds how deeply scripts may source one another, so that those
+// sourcing themselves fail rather than recurse forever.
+const max_depth = 16
+
+// source runs directives itself, so it cannot be in the literal of directives.
+func init() {
+	directives["source"] = CommandSource
+}
+
+func CommandSource(state *State, w io.Writer, r io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrSourceMissingArgs
+	}
+
+	return state.source(w, r, args[0])
+}
+
+func CommandSet(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		for _, name := range slices.Sorted(maps.Keys(state.vars)) {
+			fmt.Fprintf(w, "%s=%s\n", name, state.vars[name])
+		}
+		if state.errexit {
+			fmt.Fprintln(w, "set -e")
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "-e":
+		state.errexit = true
+		return nil
+
+	case "+e":
+		state.errexit = false
+		return nil
+	}
+
+	name := args[0]
+	if !reVariable.MatchString(name) {
+		return ErrBadVariable
+	}
+
+	if len(args) < 2 {
+		delete(state.vars, name)
+		return nil
+	}
+
+	state.vars[name] = strings.Join(args[1:], " ")
+	return nil
+}
+
+// Script runs the directives in the file at path, as the source directive
+// does, without prompting. It returns the error that aborted the script, if
+// any, other errors are written to w along with the directives' output.
+func Script(w io.Writer, path string) error {
+	state := new_state(false)
+	defer state.close()
+
+	if err := state.source(w, os.Stdin, path); err != nil && err != QuitMessage {
+		return err
+	}
+
+	return nil
+}
+
+// source runs the directives in the file at path, one per line. Errors are
+// written to w, prefixed by where they happened, unless set -e is in effect,
+// in which case the first one is returned.
+func (s *State) source(w io.Writer, r io.Reader, path string) error {
+	if s.depth >= max_depth {
+		return ErrSourceDepth
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s.depth++
+	defer func() { s.depth-- }()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		err := s.exec(w, r, scanner.Text())
+		if err == nil {
+			continue
+		}
+		if err == QuitMessage {
+			return err
+		}
+
+		err = fmt.Errorf("%s:%d: %w", path, n, err)
+		if s.errexit {
+			return err
+		}
+		fmt.Fprintln(w, err)
+	}
+
+	return scanner.Err()
+}
+
+// substitute replaces $NAME and ${NAME} by the value of the variable, or else
+// of the environment variable, with that name.
+func (s *State) substitute(line string) (string, error) {
+	var undefined string
+	line = reReference.ReplaceAllStringFunc(line, func(ref string) string {
+		name := strings.Trim(ref, "${}")
+		if v, in := s.vars[name]; in {
+			return v
+		}
+		if v, in := os.LookupEnv(name); in {
+			return v
+		}
+
+		if undefined == "" {
+			undefined = name
+		}
+		return ref
+	})
+
+	if undefined != "" {
+		return "", ErrUndefinedVariable(undefined)
+	}
+
+	return line, nil
+}
+
+// strip_comment removes everything from the first word starting with #.
+func strip_comment(line string) string {
+	for i := range len(line) {
+		if line[i] == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,196 @@
+package repl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+)
+
+// session_version is bumped on any incompatible change to the session file.
+const session_version = 1
+
+// session is the JSON representation of the state of the REPL. Models are
+// embedded, along with their metadata, which holds the training state of the
+// contexts, but datasets are referenced by path.
+type session struct {
+	Version  int               `json:"version"`
+	Focus    string            `json:"focus"`
+	Contexts []session_context `json:"contexts"`
+}
+
+type session_context struct {
+	Name    string            `json:"name"`
+	Model   *nn.NeuralNetwork `json:"model"`
+	Sources []nn.DatasetInfo  `json:"sources,omitempty"`
+	Unsaved bool              `json:"unsaved,omitempty"`
+
+	Snapshots []Snapshot `json:"snapshots,omitempty"`
+}
+
+func CommandSession(state *State, w io.Writer, r io.Reader, args ...string) error {
+	if len(args) < 2 {
+		return ErrSessionMissingArgs
+	}
+	path := args[1]
+
+	switch directive := args[0]; directive {
+	case "save":
+		snapshots := len(args) >= 3 && args[2] == "snapshots"
+		if len(args) >= 3 && !snapshots {
+			return ErrUnknownDirective(args[2])
+		}
+
+		if err := SaveSession(path, state, snapshots); err != nil {
+			return fmt.Errorf("save session: %w", err)
+		}
+
+	case "load":
+		if state.busy("") {
+			return ErrContextBusy
+		}
+
+		if state.Unsaved() {
+			overwrite, err := state.confirm(w, r, "There are unsaved changes, do you want to replace them")
+			if err != nil || !overwrite {
+				return nil
+			}
+		}
+
+		if err := LoadSession(path, state, w); err != nil {
+			return fmt.Errorf("load session: %w", err)
+		}
+
+	default:
+		return ErrUnknownDirective(directive)
+	}
+
+	return nil
+}
+
+// SaveSession stores every context of the state, and which one is focused,
+// onto the file at path, along with their named snapshots if asked to.
+func SaveSession(path string, state *State, snapshots bool) (err error) {
+	s := session{
+		Version: session_version,
+		Focus:   state.focus,
+	}
+
+	names := make([]string, 0, len(state.ctxs))
+	for name := range state.ctxs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		ctx := state.ctxs[name]
+
+		ctx.mu.Lock()
+		ctx.Sync()
+		sc := session_context{
+			Name:    name,
+			Model:   ctx.NeuralNetwork,
+			Sources: slices.Clone(ctx.Sources),
+			Unsaved: ctx.Unsaved,
+		}
+		if snapshots {
+			sc.Snapshots = slices.Clone(ctx.Snapshots)
+		}
+		s.Contexts = append(s.Contexts, sc)
+		ctx.mu.Unlock()
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s)
+}
+
+// LoadSession replaces the contexts of the state by those stored in the file
+// at path, reloading their datasets from their paths. Datasets that changed
+// since the session was saved are loaded anyway, with a warning written to w.
+// The state is left untouched on error.
+func LoadSession(path string, state *State, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var s session
+	if err := json.NewDecoder(f).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != session_version {
+		return fmt.Errorf("%w: %d", ErrSessionVersion, s.Version)
+	}
+
+	// the datasets of the contexts loaded so far are closed on error
+	ctxs := make(map[string]*Context, len(s.Contexts))
+	loaded := false
+	defer func() {
+		if !loaded {
+			for _, ctx := range ctxs {
+				ctx.close()
+			}
+		}
+	}()
+
+	for _, sc := range s.Contexts {
+		if !reName.MatchString(sc.Name) {
+			return fmt.Errorf("context %q: %w", sc.Name, ErrBadName)
+		}
+		if _, in := ctxs[sc.Name]; in {
+			return fmt.Errorf("context %q: %w", sc.Name, ErrSessionDuplicate)
+		}
+		if sc.Model == nil || sc.Model.Len() < 2 {
+			return fmt.Errorf("context %q: missing model", sc.Name)
+		}
+
+		ctx := NewContext(sc.Model)
+		ctxs[sc.Name] = ctx
+
+		for _, source := range sc.Sources {
+			info, err := ctx.Load(source.Set, source.Path, source.Extra...)
+			if err != nil {
+				return fmt.Errorf("context %q: %w", sc.Name, err)
+			}
+
+			if info.Count != source.Count || info.Hash != source.Hash {
+				fmt.Fprintf(w, "Warning: %s of %q, %s, changed since the session was saved.\n", source.Set, sc.Name, source.Path)
+			}
+		}
+		ctx.Unsaved = sc.Unsaved
+
+		size := len(sc.Model.Parameters())
+		for _, snapshot := range sc.Snapshots {
+			if len(snapshot.Parameters) != size {
+				return fmt.Errorf("context %q: snapshot %q: %w", sc.Name, snapshot.Name, ErrSnapshotMismatch)
+			}
+		}
+		ctx.Snapshots = sc.Snapshots
+	}
+
+	if _, in := ctxs[s.Focus]; !in && s.Focus != "" {
+		return ErrContextNotFound
+	}
+
+	state.close()
+	state.ctxs = ctxs
+	state.focus = s.Focus
+
+	loaded = true
+	return nil
+}
@@ -0,0 +1,179 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	nn "github.com/alan-b-lima/nn-digits/internal/neural_network"
+	"github.com/alan-b-lima/nn-digits/pkg/mem"
+)
+
+// max_undo is how many automatic snapshots are kept for each context.
+const max_undo = 16
+
+// Snapshot is a copy of the weights, biases and hyperparameters of a context,
+// along with its training state. Snapshots are named, or taken automatically
+// before a directive changes the weights, and named after it.
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+
+	Parameters   mem.Float64Slice `json:"parameters"`
+	LearningRate float64          `json:"learning_rate"`
+	BatchSize    int              `json:"batch_size,omitempty"`
+	Cycle        int              `json:"cycle"`
+	Evolution    []nn.Record      `json:"evolution,omitempty"`
+}
+
+func CommandSnapshot(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	name := fmt.Sprintf("cycle-%d", ctx.Cycle)
+	if len(args) >= 1 {
+		name = args[0]
+	}
+
+	snapshot := ctx.Snapshot(name)
+
+	i := slices.IndexFunc(ctx.Snapshots, func(s Snapshot) bool { return s.Name == name })
+	if i >= 0 {
+		ctx.Snapshots[i] = snapshot
+	} else {
+		ctx.Snapshots = append(ctx.Snapshots, snapshot)
+	}
+
+	fmt.Fprintf(w, "Took snapshot %q.\n", name)
+	return nil
+}
+
+func CommandSnapshots(state *State, w io.Writer, _ io.Reader, _ ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if len(ctx.Snapshots) > 0 {
+		t := table{columns: []string{"Cycle", "Learning rate", "Batch size", "Taken"}}
+		for _, s := range ctx.Snapshots {
+			t.row(s.Name, func(column int) string {
+				switch column {
+				case 0:
+					return fmt.Sprint(s.Cycle)
+				case 1:
+					return fmt.Sprintf("%g", s.LearningRate)
+				case 2:
+					return fmt.Sprint(s.BatchSize)
+				default:
+					return s.Created.Format(time.DateTime)
+				}
+			})
+		}
+		t.write(w)
+	}
+
+	if n := len(ctx.undo); n > 0 {
+		fmt.Fprintf(w, "\nUndo (%d): %s\n", n, ctx.undo[n-1].Name)
+	}
+
+	return nil
+}
+
+func CommandRevert(state *State, w io.Writer, _ io.Reader, args ...string) error {
+	if len(args) < 1 {
+		return ErrRevertMissingArgs
+	}
+
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+	if state.busy(state.focus) {
+		return ErrContextBusy
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	i := slices.IndexFunc(ctx.Snapshots, func(s Snapshot) bool { return s.Name == args[0] })
+	if i < 0 {
+		return ErrSnapshotNotFound
+	}
+
+	ctx.checkpoint("revert", args[0])
+	ctx.Restore(ctx.Snapshots[i])
+	return nil
+}
+
+func CommandUndo(state *State, w io.Writer, _ io.Reader, _ ...string) error {
+	ctx := state.Focused()
+	if ctx == nil {
+		return ErrNilContext
+	}
+	if state.busy(state.focus) {
+		return ErrContextBusy
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	n := len(ctx.undo)
+	if n == 0 {
+		return ErrNothingToUndo
+	}
+
+	snapshot := ctx.undo[n-1]
+	ctx.undo = ctx.undo[:n-1]
+
+	ctx.Restore(snapshot)
+	fmt.Fprintf(w, "Undid %s.\n", snapshot.Name)
+	return nil
+}
+
+// Snapshot returns a snapshot of the context with the given name.
+func (ctx *Context) Snapshot(name string) Snapshot {
+	return Snapshot{
+		Name:         name,
+		Created:      time.Now().Truncate(time.Second),
+		Parameters:   ctx.NeuralNetwork.Parameters(),
+		LearningRate: ctx.LearningRate,
+		BatchSize:    ctx.BatchSize,
+		Cycle:        ctx.Cycle,
+		Evolution:    slices.Clone(ctx.Evolution),
+	}
+}
+
+// Restore brings the context back to the given snapshot.
+//
+// Restore panics if the snapshot was taken from a network of other dimensions.
+func (ctx *Context) Restore(s Snapshot) {
+	ctx.NeuralNetwork.SetParameters(s.Parameters)
+	ctx.LearningRate = s.LearningRate
+	ctx.BatchSize = s.BatchSize
+	ctx.Cycle = s.Cycle
+	ctx.Evolution = slices.Clone(s.Evolution)
+	ctx.Unsaved = true
+}
+
+// checkpoint takes an automatic snapshot of the context, named after the
+// directive about to change it, onto the undo stack, dropping the oldest one
+// if it is full.
+func (ctx *Context) checkpoint(directive string, args ...string) {
+	name := strings.Join(append([]string{directive}, args...), " ")
+
+	ctx.undo = append(ctx.undo, ctx.Snapshot(name))
+	if len(ctx.undo) > max_undo {
+		ctx.undo = slices.Delete(ctx.undo, 0, len(ctx.undo)-max_undo)
+	}
+}